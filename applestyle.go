@@ -0,0 +1,152 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micromdm/plist"
+)
+
+// appleStyleTrailingKeys are the common payload keys which Apple's and
+// ProfileCreator's output places, in this order, after the other keys of
+// profile and payload dictionaries.
+var appleStyleTrailingKeys = []string{
+	"PayloadDisplayName",
+	"PayloadIdentifier",
+	"PayloadType",
+	"PayloadUUID",
+	"PayloadVersion",
+}
+
+// MarshalAppleStyle marshals the profile formatted like profiles written
+// by Apple's tools and ProfileCreator to minimize diffs against them:
+// tab indentation with the top level dictionary unindented, <data>
+// wrapped at 76 columns less the indentation (counting a tab as eight
+// columns) and, in profile and payload dictionaries, the other keys in
+// sorted order followed by PayloadDisplayName, PayloadIdentifier,
+// PayloadType, PayloadUUID and PayloadVersion.
+func (p *Profile) MarshalAppleStyle() ([]byte, error) {
+	b, err := plist.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := plist.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(plistDocStart + "\n")
+	if err := writeAppleStyle(&buf, v, 0); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n" + plistDocEnd)
+	return buf.Bytes(), nil
+}
+
+// writeAppleStyle writes the property list value v at indentation depth.
+// The first line is not indented and no trailing newline is written.
+func writeAppleStyle(buf *bytes.Buffer, v interface{}, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString("<dict/>")
+			return nil
+		}
+		buf.WriteString("<dict>\n")
+		for _, k := range appleStyleKeys(v) {
+			buf.WriteString(indent + "\t<key>" + escapeAppleStyle(k) + "</key>\n" + indent + "\t")
+			if err := writeAppleStyle(buf, v[k], depth+1); err != nil {
+				return err
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "</dict>")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("<array/>")
+			return nil
+		}
+		buf.WriteString("<array>\n")
+		for _, e := range v {
+			buf.WriteString(indent + "\t")
+			if err := writeAppleStyle(buf, e, depth+1); err != nil {
+				return err
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "</array>")
+	case string:
+		buf.WriteString("<string>" + escapeAppleStyle(v) + "</string>")
+	case bool:
+		if v {
+			buf.WriteString("<true/>")
+		} else {
+			buf.WriteString("<false/>")
+		}
+	case int64:
+		buf.WriteString("<integer>" + strconv.FormatInt(v, 10) + "</integer>")
+	case uint64:
+		buf.WriteString("<integer>" + strconv.FormatUint(v, 10) + "</integer>")
+	case float64:
+		buf.WriteString("<real>" + strconv.FormatFloat(v, 'g', -1, 64) + "</real>")
+	case time.Time:
+		buf.WriteString("<date>" + v.UTC().Format("2006-01-02T15:04:05Z") + "</date>")
+	case []byte:
+		buf.WriteString("<data>\n")
+		// indentation beyond eight tabs does not shorten lines further
+		cols := 76 - 8*depth
+		if depth > 8 {
+			cols = 76 - 8*8
+		}
+		data := base64.StdEncoding.EncodeToString(v)
+		for len(data) > 0 {
+			n := cols
+			if n > len(data) {
+				n = len(data)
+			}
+			buf.WriteString(indent + data[:n] + "\n")
+			data = data[n:]
+		}
+		buf.WriteString(indent + "</data>")
+	default:
+		return fmt.Errorf("unsupported property list value: %T", v)
+	}
+	return nil
+}
+
+// appleStyleKeys returns the keys of dictionary m in Apple style order.
+// Only profile and payload dictionaries, which have a PayloadType, move
+// the common payload keys to the end.
+func appleStyleKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	trailing := make(map[string]bool)
+	if _, ok := m["PayloadType"]; ok {
+		for _, k := range appleStyleTrailingKeys {
+			trailing[k] = true
+		}
+	}
+	for k := range m {
+		if !trailing[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range appleStyleTrailingKeys {
+		if _, ok := m[k]; ok && trailing[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// escapeAppleStyle escapes the XML special characters of s. Unlike
+// encoding/xml newlines and tabs are kept as is.
+func escapeAppleStyle(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
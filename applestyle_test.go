@@ -0,0 +1,24 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfileMarshalAppleStyle(t *testing.T) {
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "apple-style.mobileconfig"))
+	fatalIf(t, err)
+	p, err := ParseProfile(want)
+	fatalIf(t, err)
+	if len(p.WiFiPayloads()) != 1 || len(p.CertificateRootPayloads()) != 1 {
+		t.Fatal("unexpected payloads")
+	}
+
+	have, err := p.MarshalAppleStyle()
+	fatalIf(t, err)
+	if !bytes.Equal(have, want) {
+		t.Errorf("have %s, want %s", have, want)
+	}
+}
@@ -0,0 +1,99 @@
+package cfgprofiles
+
+import (
+	"crypto/x509"
+	"errors"
+)
+
+// ErrNotBound is returned when a payload method requires the profile the
+// payload belongs to and the payload is not bound to one (see Bind).
+var ErrNotBound = errors.New("payload is not bound to a profile")
+
+// Bind sets the parent profile of each payload of the profile which
+// refers to other payloads (Wi-Fi, VPN, FileVault recovery key escrow
+// and MDM payloads) to p so that the references, such as
+// PayloadCertificateUUID, can be resolved without passing the profile,
+// e.g. with WiFiPayload.ResolveCertificate. Payloads added later must be
+// bound by calling Bind again.
+//
+// Binding is opt-in as it makes payloads refer back to the profile: bound
+// payloads are not reflect.DeepEqual to unbound ones, nor to payloads
+// bound to another profile.
+func (p *Profile) Bind() {
+	for _, pc := range p.PayloadContent {
+		if b, ok := pc.Payload.(binder); ok {
+			b.bind(p)
+		}
+	}
+}
+
+// binder is implemented by payloads which embed binding.
+type binder interface {
+	Parent() *Profile
+	bind(p *Profile)
+}
+
+// binding is embedded in payloads whose references to other payloads
+// can be resolved using the profile they are bound to by Profile.Bind.
+type binding struct {
+	parent *Profile
+}
+
+// Parent returns the profile the payload is bound to by Profile.Bind or
+// nil if it is not bound.
+func (b *binding) Parent() *Profile {
+	return b.parent
+}
+
+func (b *binding) bind(p *Profile) {
+	b.parent = p
+}
+
+// boundProfile returns the profile the payload is bound to or
+// ErrNotBound.
+func (b *binding) boundProfile() (*Profile, error) {
+	if b.parent == nil {
+		return nil, ErrNotBound
+	}
+	return b.parent, nil
+}
+
+// ResolveCertificate is like Certificate using the profile the payload is
+// bound to (see Profile.Bind).
+func (pl *WiFiPayload) ResolveCertificate() (*x509.Certificate, error) {
+	p, err := pl.boundProfile()
+	if err != nil {
+		return nil, err
+	}
+	return pl.Certificate(p)
+}
+
+// ResolveCertificate is like Certificate using the profile the payload is
+// bound to (see Profile.Bind).
+func (pl *VPNPayload) ResolveCertificate() (*x509.Certificate, error) {
+	p, err := pl.boundProfile()
+	if err != nil {
+		return nil, err
+	}
+	return pl.Certificate(p)
+}
+
+// ResolveCertificate is like Certificate using the profile the payload is
+// bound to (see Profile.Bind).
+func (pl *FDERecoveryKeyEscrowPayload) ResolveCertificate() (*x509.Certificate, error) {
+	p, err := pl.boundProfile()
+	if err != nil {
+		return nil, err
+	}
+	return pl.Certificate(p)
+}
+
+// ResolveIdentityCertificate is like IdentityCertificate using the
+// profile the payload is bound to (see Profile.Bind).
+func (pl *MDMPayload) ResolveIdentityCertificate() (*x509.Certificate, error) {
+	p, err := pl.boundProfile()
+	if err != nil {
+		return nil, err
+	}
+	return pl.IdentityCertificate(p)
+}
@@ -0,0 +1,64 @@
+package cfgprofiles
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestProfileBind(t *testing.T) {
+	cert := GetCertData(t)
+	p := NewProfile("com.example.profile")
+	uuids := p.AddCertificateChain([]*x509.Certificate{cert})
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	wifi.EAPClientConfiguration = &EAPClientConfiguration{}
+	wifi.PayloadCertificateUUID = uuids[0]
+	p.AddPayload(wifi)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	p2 := new(Profile)
+	fatalIf(t, plist.Unmarshal(b, p2))
+	wifi2 := p2.WiFiPayloads()[0]
+	if _, err := wifi2.ResolveCertificate(); !errors.Is(err, ErrNotBound) {
+		t.Errorf("have %v, want %v", err, ErrNotBound)
+	}
+
+	p2.Bind()
+	if wifi2.Parent() != p2 {
+		t.Error("expected payload to be bound to profile")
+	}
+	c, err := wifi2.ResolveCertificate()
+	fatalIf(t, err)
+	if !c.Equal(cert) {
+		t.Error("expected certificate to match")
+	}
+
+	// the back-reference is not promoted onto the profile
+	if _, ok := interface{}(p2).(binder); ok {
+		t.Error("expected profile not to be bindable")
+	}
+
+	// bound payloads with no other keys set are empty
+	empty := NewMDMPayload("com.example.profile.mdm")
+	p2.AddPayload(empty)
+	p2.Bind()
+	if !isEmptyPayload(empty) {
+		t.Error("expected bound payload to be empty")
+	}
+	p2.PayloadContent = p2.PayloadContent[:len(p2.PayloadContent)-1]
+
+	c2 := p2.Clone()
+	if have := c2.WiFiPayloads()[0].Parent(); have != c2 {
+		t.Error("expected cloned payload to be bound to the clone")
+	}
+
+	// binding is not marshaled
+	b2, err := plist.Marshal(p2)
+	fatalIf(t, err)
+	if string(b2) != string(b) {
+		t.Error("expected bound profile to marshal unchanged")
+	}
+}
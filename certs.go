@@ -0,0 +1,199 @@
+package cfgprofiles
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// CertificatePayload is implemented by payloads which contain a certificate.
+type CertificatePayload interface {
+	// Certificate parses and returns the payload's certificate.
+	Certificate() (*x509.Certificate, error)
+}
+
+// Certificate parses the DER encoded certificate in PayloadContent.
+func (pl *CertificatePKCS1Payload) Certificate() (*x509.Certificate, error) {
+	return x509.ParseCertificate(pl.PayloadContent)
+}
+
+// Certificate parses the DER encoded certificate in PayloadContent.
+func (pl *CertificateRootPayload) Certificate() (*x509.Certificate, error) {
+	return x509.ParseCertificate(pl.PayloadContent)
+}
+
+// Certificate parses the PEM encoded certificate in PayloadContent.
+func (pl *CertificatePEMPayload) Certificate() (*x509.Certificate, error) {
+	block, _ := pem.Decode(pl.PayloadContent)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// Certificate decrypts PayloadContent with Password and returns the
+// certificate of the PKCS #12 identity.
+func (pl *CertificatePKCS12Payload) Certificate() (*x509.Certificate, error) {
+	cert, _, err := pl.Identity()
+	return cert, err
+}
+
+// Certificate parses the DER or PEM encoded certificate in PayloadContent.
+func (pl *CertificateGenericPayload) Certificate() (*x509.Certificate, error) {
+	if block, _ := pem.Decode(pl.PayloadContent); block != nil && block.Type == "CERTIFICATE" {
+		return x509.ParseCertificate(block.Bytes)
+	}
+	return x509.ParseCertificate(pl.PayloadContent)
+}
+
+// setDisplayNameFromCert sets the PayloadDisplayName of pld to the
+// common name of cert, or its full subject if it has none, and the
+// PayloadDescription to its issuer and validity period.
+func setDisplayNameFromCert(pld *Payload, cert *x509.Certificate) {
+	name := cert.Subject.CommonName
+	if name == "" {
+		name = cert.Subject.String()
+	}
+	issuer := cert.Issuer.CommonName
+	if issuer == "" {
+		issuer = cert.Issuer.String()
+	}
+	pld.PayloadDisplayName = name
+	pld.PayloadDescription = fmt.Sprintf(
+		"Issued by %s, valid from %s to %s",
+		issuer,
+		cert.NotBefore.UTC().Format("2006-01-02"),
+		cert.NotAfter.UTC().Format("2006-01-02"),
+	)
+}
+
+// SetDisplayNameFromCert sets the PayloadDisplayName and
+// PayloadDescription from the subject, issuer and validity of the
+// certificate in PayloadContent.
+func (pl *CertificatePKCS1Payload) SetDisplayNameFromCert() error {
+	cert, err := pl.Certificate()
+	if err != nil {
+		return err
+	}
+	setDisplayNameFromCert(&pl.Payload, cert)
+	return nil
+}
+
+// SetDisplayNameFromCert sets the PayloadDisplayName and
+// PayloadDescription from the subject, issuer and validity of the
+// certificate in PayloadContent.
+func (pl *CertificateRootPayload) SetDisplayNameFromCert() error {
+	cert, err := pl.Certificate()
+	if err != nil {
+		return err
+	}
+	setDisplayNameFromCert(&pl.Payload, cert)
+	return nil
+}
+
+// SetDisplayNameFromCert sets the PayloadDisplayName and
+// PayloadDescription from the subject, issuer and validity of the
+// certificate in PayloadContent.
+func (pl *CertificatePEMPayload) SetDisplayNameFromCert() error {
+	cert, err := pl.Certificate()
+	if err != nil {
+		return err
+	}
+	setDisplayNameFromCert(&pl.Payload, cert)
+	return nil
+}
+
+// IsCertificate reports whether the PayloadType is one of the
+// certificate payload types or an unmodeled "com.apple.security." type,
+// whose certificate is kept in a CertificateGenericPayload.
+func (pld *Payload) IsCertificate() bool {
+	return isCertificatePayloadType(pld.PayloadType)
+}
+
+// CertificatePayloads returns a slice of all payloads which contain a certificate
+func (p *Profile) CertificatePayloads() (plds []CertificatePayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(CertificatePayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// resolveCertificate finds the certificate payload with PayloadUUID u in
+// profile p and parses its certificate.
+func resolveCertificate(p *Profile, u string) (*x509.Certificate, error) {
+	if u == "" {
+		return nil, errors.New("empty certificate payload UUID")
+	}
+	pld := p.PayloadByUUID(u)
+	if pld == nil {
+		return nil, fmt.Errorf("no payload with UUID %q", u)
+	}
+	certPld, ok := pld.(CertificatePayload)
+	if !ok {
+		return nil, fmt.Errorf("payload %q: %T is not a certificate payload", u, pld)
+	}
+	cert, err := certPld.Certificate()
+	if err != nil {
+		return nil, fmt.Errorf("payload %q: %w", u, err)
+	}
+	return cert, nil
+}
+
+// Certificate resolves PayloadCertificateUUID to a certificate payload in
+// profile p and returns its parsed certificate.
+func (pl *WiFiPayload) Certificate(p *Profile) (*x509.Certificate, error) {
+	return resolveCertificate(p, pl.PayloadCertificateUUID)
+}
+
+// TrustAnchors resolves the EAP PayloadCertificateAnchorUUIDs to
+// certificate payloads in profile p and returns their parsed certificates
+// in order. Together with TLSTrustedServerNames these form the trust
+// policy for the network's authentication server. An error is returned if
+// any UUID does not refer to a certificate payload.
+func (pl *WiFiPayload) TrustAnchors(p *Profile) ([]*x509.Certificate, error) {
+	if pl.EAPClientConfiguration == nil {
+		return nil, nil
+	}
+	var certs []*x509.Certificate
+	for _, u := range pl.EAPClientConfiguration.PayloadCertificateAnchorUUID {
+		cert, err := resolveCertificate(p, u)
+		if err != nil {
+			return nil, fmt.Errorf("trust anchor: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// Certificate resolves the PayloadCertificateUUID of the IKEv2 or VPN
+// settings to a certificate payload in profile p and returns its parsed
+// certificate.
+func (pl *VPNPayload) Certificate(p *Profile) (*x509.Certificate, error) {
+	var u string
+	if pl.IKEv2 != nil && pl.IKEv2.PayloadCertificateUUID != "" {
+		u = pl.IKEv2.PayloadCertificateUUID
+	} else if pl.VPN != nil {
+		u = pl.VPN.PayloadCertificateUUID
+	}
+	return resolveCertificate(p, u)
+}
+
+// ErrIdentityNotIssued is returned when an identity is provided by a SCEP
+// or ACME payload. Its certificate is only issued to the device when the
+// profile is installed.
+var ErrIdentityNotIssued = errors.New("identity certificate is issued on the device")
+
+// IdentityCertificate resolves IdentityCertificateUUID to a payload in
+// profile p and returns its parsed certificate. If the identity is
+// provided by a SCEP or ACME payload there is no certificate in the
+// profile and an error wrapping ErrIdentityNotIssued is returned.
+func (pl *MDMPayload) IdentityCertificate(p *Profile) (*x509.Certificate, error) {
+	switch p.PayloadByUUID(pl.IdentityCertificateUUID).(type) {
+	case *SCEPPayload, *ACMECertificatePayload:
+		return nil, fmt.Errorf("payload %q: %w", pl.IdentityCertificateUUID, ErrIdentityNotIssued)
+	}
+	return resolveCertificate(p, pl.IdentityCertificateUUID)
+}
@@ -0,0 +1,245 @@
+package cfgprofiles
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestWiFiAndVPNCertificate(t *testing.T) {
+	cert := GetCertData(t)
+	p := NewProfile("com.example.profile")
+	uuids := p.AddCertificateChain([]*x509.Certificate{cert})
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	p.AddPayload(mdm)
+
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	wifi.PayloadCertificateUUID = uuids[0]
+	c, err := wifi.Certificate(p)
+	fatalIf(t, err)
+	if !c.Equal(cert) {
+		t.Error("expected certificate to match")
+	}
+
+	vpn := NewVPNPayload("com.example.profile.vpn")
+	vpn.IKEv2 = &VPNIKEv2{PayloadCertificateUUID: uuids[0]}
+	c, err = vpn.Certificate(p)
+	fatalIf(t, err)
+	if !c.Equal(cert) {
+		t.Error("expected certificate to match")
+	}
+
+	for _, u := range []string{"", "missing", mdm.PayloadUUID} {
+		wifi.PayloadCertificateUUID = u
+		if _, err := wifi.Certificate(p); err == nil {
+			t.Errorf("expected an error for UUID %q", u)
+		}
+	}
+}
+
+func TestCertificatePayloads(t *testing.T) {
+	cert := GetCertData(t)
+	pemBytes, err := ioutil.ReadFile(filepath.Join("testdata", "entrust.pem"))
+	fatalIf(t, err)
+
+	p := NewProfile("com.example.profile")
+	pkcs1 := NewCertificatePKCS1Payload("com.example.profile.pkcs1")
+	pkcs1.PayloadContent = cert.Raw
+	p.AddPayload(pkcs1)
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	root := NewCertificateRootPayload("com.example.profile.root")
+	root.PayloadContent = cert.Raw
+	p.AddPayload(root)
+	pemPld := NewCertificatePEMPayload("com.example.profile.pem")
+	pemPld.PayloadContent = pemBytes
+	p.AddPayload(pemPld)
+
+	plds := p.CertificatePayloads()
+	if len(plds) != 3 {
+		t.Fatalf("have %d payloads, want %d", len(plds), 3)
+	}
+	for _, pld := range plds {
+		c, err := pld.Certificate()
+		fatalIf(t, err)
+		if !c.Equal(cert) {
+			t.Error("expected certificate to match")
+		}
+		if !CommonPayload(pld).IsCertificate() {
+			t.Error("expected certificate payload type")
+		}
+	}
+	if NewMDMPayload("com.example.mdm").IsCertificate() {
+		t.Error("expected MDM payload not to be a certificate")
+	}
+}
+
+func TestIsCertificateConsistent(t *testing.T) {
+	cert := GetCertData(t)
+	generic := &CertificateGenericPayload{
+		Payload:        *NewPayload("com.apple.security.pkcs7", "com.example.profile.pkcs7"),
+		PayloadContent: cert.Raw,
+	}
+	p := NewProfile("com.example.profile")
+	for _, pld := range []interface{}{
+		NewCertificatePKCS1Payload("com.example.profile.pkcs1"),
+		NewCertificateRootPayload("com.example.profile.root"),
+		NewCertificatePEMPayload("com.example.profile.pem"),
+		NewCertificatePKCS12Payload("com.example.profile.pkcs12"),
+		generic,
+		NewMDMPayload("com.example.profile.mdm"),
+		NewSCEPPayload("com.example.profile.scep"),
+		NewWiFiPayload("com.example.profile.wifi"),
+		NewCertificateTransparencyPayload("com.example.profile.ct"),
+	} {
+		p.AddPayload(pld)
+	}
+
+	certs := make(map[string]bool)
+	for _, pld := range p.CertificatePayloads() {
+		certs[CommonPayload(pld).PayloadUUID] = true
+	}
+	if have, want := len(certs), 5; have != want {
+		t.Errorf("have %d certificate payloads, want %d", have, want)
+	}
+	for _, pc := range p.PayloadContent {
+		c := CommonPayload(pc.Payload)
+		want := certs[c.PayloadUUID]
+		if have := c.IsCertificate(); have != want {
+			t.Errorf("%s: IsCertificate: have %v, want %v", c.PayloadType, have, want)
+		}
+		if have := isCertificatePayloadType(c.PayloadType); have != want {
+			t.Errorf("%s: isCertificatePayloadType: have %v, want %v", c.PayloadType, have, want)
+		}
+	}
+}
+
+func TestWiFiTrustAnchors(t *testing.T) {
+	cert := GetCertData(t)
+	p := NewProfile("com.example.profile")
+	uuids := p.AddCertificateChain([]*x509.Certificate{cert})
+
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	anchors, err := wifi.TrustAnchors(p)
+	fatalIf(t, err)
+	if len(anchors) != 0 {
+		t.Errorf("have %d anchors, want 0", len(anchors))
+	}
+
+	wifi.EAPClientConfiguration = &EAPClientConfiguration{
+		PayloadCertificateAnchorUUID: uuids,
+		TLSTrustedServerNames:        []string{"radius.example.com"},
+	}
+	anchors, err = wifi.TrustAnchors(p)
+	fatalIf(t, err)
+	if len(anchors) != 1 || !anchors[0].Equal(cert) {
+		t.Error("expected trust anchor to match")
+	}
+
+	wifi.EAPClientConfiguration.PayloadCertificateAnchorUUID = append(uuids, "dangling")
+	if _, err := wifi.TrustAnchors(p); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestCertificateGenericPayload(t *testing.T) {
+	cert := GetCertData(t)
+	p := NewProfile("com.example.profile")
+	pld := NewPayload("com.apple.security.pkcs7", "com.example.profile.pkcs7")
+	p.AddPayload(pld)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	// without certificate data the payload stays unknown
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	if len(p2.UnknownPayloads()) != 1 {
+		t.Fatal("expected an unknown payload")
+	}
+
+	generic := &CertificateGenericPayload{Payload: *pld, PayloadContent: cert.Raw}
+	p.PayloadContent[0].Payload = generic
+	b, err = plist.Marshal(p)
+	fatalIf(t, err)
+	p2 = &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	plds := p2.CertificateGenericPayloads()
+	if len(plds) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	c, err := plds[0].Certificate()
+	fatalIf(t, err)
+	if !c.Equal(cert) {
+		t.Error("expected certificate to match")
+	}
+
+	if err := UnmarshalStrict(b, &Profile{}); err == nil {
+		t.Error("expected an error")
+	}
+
+	if isCertificatePayloadType(PayloadTypeSCEP) || !isCertificatePayloadType(PayloadTypeCertificatePKCS12) {
+		t.Error("unexpected certificate payload type family")
+	}
+}
+
+func TestCertificateSetDisplayNameFromCert(t *testing.T) {
+	pemBytes, err := ioutil.ReadFile(filepath.Join("testdata", "entrust.pem"))
+	fatalIf(t, err)
+	pl := NewCertificatePEMPayload("com.example.profile.pem")
+	pl.PayloadContent = pemBytes
+	fatalIf(t, pl.SetDisplayNameFromCert())
+
+	if have, want := pl.PayloadDisplayName, "Entrust Root Certification Authority - G2"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	want := "Issued by Entrust Root Certification Authority - G2, valid from 2009-07-07 to 2030-12-07"
+	if have := pl.PayloadDescription; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	pkcs1 := NewCertificatePKCS1Payload("com.example.profile.pkcs1")
+	pkcs1.PayloadDisplayName = "Unchanged"
+	if err := pkcs1.SetDisplayNameFromCert(); err == nil {
+		t.Error("expected an error")
+	}
+	if have, want := pkcs1.PayloadDisplayName, "Unchanged"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
+
+func TestMDMIdentityCertificate(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	p.AddPayload(mdm)
+
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	if _, err := mdm.IdentityCertificate(p); !errors.Is(err, ErrIdentityNotIssued) {
+		t.Errorf("have %v, want ErrIdentityNotIssued", err)
+	}
+
+	p12, err := ioutil.ReadFile(filepath.Join("testdata", "identity-aes.p12"))
+	fatalIf(t, err)
+	pkcs12 := NewCertificatePKCS12Payload("com.example.profile.pkcs12")
+	pkcs12.PayloadContent = p12
+	pkcs12.Password = "secret"
+	p.AddPayload(pkcs12)
+	mdm.IdentityCertificateUUID = pkcs12.PayloadUUID
+	c, err := mdm.IdentityCertificate(p)
+	fatalIf(t, err)
+	if have, want := c.Subject.CommonName, "PKCS12 Test"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	for _, u := range []string{"", "missing", mdm.PayloadUUID} {
+		mdm.IdentityCertificateUUID = u
+		_, err := mdm.IdentityCertificate(p)
+		if err == nil || errors.Is(err, ErrIdentityNotIssued) {
+			t.Errorf("have %v, want an error for UUID %q", err, u)
+		}
+	}
+}
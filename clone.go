@@ -0,0 +1,77 @@
+package cfgprofiles
+
+import "reflect"
+
+// Clone returns a deep copy of the profile and all of its payloads.
+// Payloads bound to p (see Bind) are bound to the copy.
+func (p *Profile) Clone() *Profile {
+	c := deepCopy(reflect.ValueOf(p)).Interface().(*Profile)
+	for i, pc := range p.PayloadContent {
+		if b, ok := pc.Payload.(binder); ok && b.Parent() == p {
+			c.PayloadContent[i].Payload.(binder).bind(c)
+		}
+	}
+	return c
+}
+
+// deepCopy returns a deep copy of v. Unexported struct fields are copied
+// shallowly.
+func deepCopy(v reflect.Value) reflect.Value {
+	return copyValue(v, nil)
+}
+
+// copyValue returns a deep copy of v. If f is not nil string values are
+// replaced with the result of f.
+func copyValue(v reflect.Value, f func(string) string) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		if f == nil {
+			return v
+		}
+		return reflect.ValueOf(f(v.String())).Convert(v.Type())
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		n := reflect.New(v.Type().Elem())
+		n.Elem().Set(copyValue(v.Elem(), f))
+		return n
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		n := reflect.New(v.Type()).Elem()
+		n.Set(copyValue(v.Elem(), f))
+		return n
+	case reflect.Struct:
+		n := reflect.New(v.Type()).Elem()
+		n.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if n.Field(i).CanSet() {
+				n.Field(i).Set(copyValue(v.Field(i), f))
+			}
+		}
+		return n
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		n := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(copyValue(v.Index(i), f))
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		n := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			n.SetMapIndex(iter.Key(), copyValue(iter.Value(), f))
+		}
+		return n
+	default:
+		return v
+	}
+}
@@ -0,0 +1,33 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProfileClone(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	now := time.Now()
+	p.PayloadDate = &now
+	p.ConsentText = map[string]string{"default": "consent"}
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadContent.Subject = [][][]string{{{"CN", "device"}}}
+	scep.PayloadContent.SubjectAltName = &SubjectAltName{DNSNames: MultiString{"a.example.com"}}
+	p.AddPayload(scep)
+
+	c := p.Clone()
+	if !reflect.DeepEqual(c, p) {
+		t.Fatalf("have %#+v, want %#+v", c, p)
+	}
+
+	cscep := c.SCEPPayloads()[0]
+	cscep.PayloadContent.Subject[0][0][1] = "changed"
+	cscep.PayloadContent.SubjectAltName.DNSNames[0] = "changed"
+	c.ConsentText["default"] = "changed"
+	if scep.PayloadContent.Subject[0][0][1] != "device" ||
+		scep.PayloadContent.SubjectAltName.DNSNames[0] != "a.example.com" ||
+		p.ConsentText["default"] != "consent" {
+		t.Error("expected clone not to share data with the original")
+	}
+}
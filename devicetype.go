@@ -0,0 +1,68 @@
+package cfgprofiles
+
+// TargetDeviceType values of the profile. A profile with a
+// TargetDeviceType other than Any is installed on a device paired with
+// an iPhone, such as an Apple Watch or Apple TV, rather than on the
+// iPhone itself.
+const (
+	TargetDeviceTypeAny       = 0
+	TargetDeviceTypeWatch     = 1
+	TargetDeviceTypeHomePod   = 2
+	TargetDeviceTypeVisionPro = 3
+	TargetDeviceTypeAppleTV   = 4
+)
+
+// payloadTypeDeviceTypes are the TargetDeviceTypes, other than Any, to
+// which each PayloadType applies. PayloadTypes which are not listed are
+// assumed to apply to all device types; a listed PayloadType with no
+// device types, such as a macOS-only one, applies to none of them.
+var payloadTypeDeviceTypes = map[string][]int{
+	PayloadTypeCustomSettings:           nil,
+	PayloadTypeFDERecoveryKeyEscrow:     nil,
+	PayloadTypeFinder:                   nil,
+	PayloadTypeManagedPreferences:       nil,
+	PayloadTypePPPC:                     nil,
+	PayloadTypeSetupAssistant:           nil,
+	PayloadTypeUniversalAccess:          nil,
+	"com.apple.system-extension-policy": nil,
+	PayloadTypeACME:                     {TargetDeviceTypeWatch},
+	PayloadTypeMDM:                      {TargetDeviceTypeWatch},
+	PayloadTypeSCEP:                     {TargetDeviceTypeWatch},
+}
+
+// PayloadsForDeviceType returns the payloads of the profile which apply
+// to TargetDeviceType t, in order. All payloads apply to
+// TargetDeviceTypeAny. For other device types, payloads which are not
+// payload structs, such as nil payloads, are omitted as their PayloadType
+// is not known.
+func (p *Profile) PayloadsForDeviceType(t int) (plds []interface{}) {
+	for _, pc := range p.PayloadContent {
+		if t == TargetDeviceTypeAny {
+			plds = append(plds, pc.Payload)
+			continue
+		}
+		c := CommonPayload(pc.Payload)
+		if c == nil {
+			continue
+		}
+		if payloadTypeAppliesTo(c.PayloadType, t) {
+			plds = append(plds, pc.Payload)
+		}
+	}
+	return
+}
+
+// payloadTypeAppliesTo reports whether PayloadType pt applies to
+// TargetDeviceType t according to payloadTypeDeviceTypes.
+func payloadTypeAppliesTo(pt string, t int) bool {
+	types, ok := payloadTypeDeviceTypes[pt]
+	if !ok {
+		return true
+	}
+	for _, dt := range types {
+		if dt == t {
+			return true
+		}
+	}
+	return false
+}
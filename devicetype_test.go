@@ -0,0 +1,35 @@
+package cfgprofiles
+
+import "testing"
+
+func TestProfilePayloadsForDeviceType(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	p.AddPayload(wifi)
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	p.AddPayload(NewPPPCPayload("com.example.profile.pppc"))
+
+	for _, tt := range []struct {
+		name string
+		t    int
+		want []interface{}
+	}{
+		{"any", TargetDeviceTypeAny, []interface{}{wifi, scep, p.PayloadContent[2].Payload}},
+		{"watch", TargetDeviceTypeWatch, []interface{}{wifi, scep}},
+		{"homepod", TargetDeviceTypeHomePod, []interface{}{wifi}},
+		{"tvos", TargetDeviceTypeAppleTV, []interface{}{wifi}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			plds := p.PayloadsForDeviceType(tt.t)
+			if len(plds) != len(tt.want) {
+				t.Fatalf("have %d payloads, want %d", len(plds), len(tt.want))
+			}
+			for i := range plds {
+				if plds[i] != tt.want[i] {
+					t.Errorf("payload %d: have %T, want %T", i, plds[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package cfgprofiles
+
+// payloadTypeDisplayNames are default display names for PayloadTypes.
+var payloadTypeDisplayNames = map[string]string{
+	PayloadTypeConfiguration:           "Configuration Profile",
+	PayloadTypeCertificatePKCS1:        "Certificate",
+	PayloadTypeCertificateRoot:         "Root Certificate",
+	PayloadTypeCertificatePEM:          "Certificate",
+	PayloadTypeCertificatePKCS12:       "Identity Certificate",
+	PayloadTypeSCEP:                    "SCEP Certificate",
+	PayloadTypeACME:                    "ACME Certificate",
+	PayloadTypeMDM:                     "Mobile Device Management",
+	PayloadTypeWiFi:                    "Wi-Fi",
+	PayloadTypeVPN:                     "VPN",
+	PayloadTypeRestrictions:            "Restrictions",
+	PayloadTypeUniversalAccess:         "Accessibility",
+	PayloadTypeFinder:                  "Finder",
+	PayloadTypeSetupAssistant:          "Setup Assistant",
+	PayloadTypeAppConfig:               "App Configuration",
+	PayloadTypeCertificateTransparency: "Certificate Transparency",
+	PayloadTypeCustomSettings:          "Custom Settings",
+	PayloadTypeManagedPreferences:      "Managed Preferences",
+	PayloadTypeProfileRemovalPassword:  "Profile Removal Password",
+	PayloadTypeGlobalHTTPProxy:         "Global HTTP Proxy",
+	PayloadTypeApplicationAccess:       "Application Access",
+	PayloadTypeFDERecoveryKeyEscrow:    "FileVault Recovery Key Escrow",
+	PayloadTypeExtensibleSSO:           "Single Sign-On Extension",
+	PayloadTypePPPC:                    "Privacy Preferences Policy Control",
+}
+
+// defaultDisplayName returns a display name for PayloadType t. The
+// PayloadType itself is used for unknown types.
+func defaultDisplayName(t string) string {
+	if name, ok := payloadTypeDisplayNames[t]; ok {
+		return name
+	}
+	return t
+}
+
+// EnsureDisplayNames sets a default PayloadDisplayName on the profile and
+// each payload where it is empty. The profile defaults to defaultPrefix
+// and payloads to a name derived from their PayloadType, prefixed with
+// defaultPrefix if not empty (e.g. "Example SCEP Certificate").
+func (p *Profile) EnsureDisplayNames(defaultPrefix string) {
+	if p.PayloadDisplayName == "" {
+		p.PayloadDisplayName = defaultPrefix
+		if defaultPrefix == "" {
+			p.PayloadDisplayName = defaultDisplayName(PayloadTypeConfiguration)
+		}
+	}
+	for _, pc := range p.PayloadContent {
+		pld := CommonPayload(pc.Payload)
+		if pld == nil || pld.PayloadDisplayName != "" {
+			continue
+		}
+		pld.PayloadDisplayName = defaultDisplayName(pld.PayloadType)
+		if defaultPrefix != "" {
+			pld.PayloadDisplayName = defaultPrefix + " " + pld.PayloadDisplayName
+		}
+	}
+}
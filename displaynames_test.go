@@ -0,0 +1,33 @@
+package cfgprofiles
+
+import "testing"
+
+func TestEnsureDisplayNames(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	mdm.PayloadDisplayName = "MDM"
+	p.AddPayload(mdm)
+	unknown := NewPayload("com.example.unknown", "com.example.profile.unknown")
+	p.AddPayload(unknown)
+
+	p.EnsureDisplayNames("Example")
+
+	for _, tt := range []struct{ have, want string }{
+		{p.PayloadDisplayName, "Example"},
+		{scep.PayloadDisplayName, "Example SCEP Certificate"},
+		{mdm.PayloadDisplayName, "MDM"},
+		{unknown.PayloadDisplayName, "Example com.example.unknown"},
+	} {
+		if tt.have != tt.want {
+			t.Errorf("have %q, want %q", tt.have, tt.want)
+		}
+	}
+
+	p = NewProfile("com.example.profile")
+	p.EnsureDisplayNames("")
+	if have, want := p.PayloadDisplayName, "Configuration Profile"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
@@ -0,0 +1,66 @@
+package cfgprofiles
+
+import "reflect"
+
+// RemoveEmptyPayloads removes the payloads which have no keys set beyond
+// the common payload keys and returns the number of payloads removed.
+// A key is unset if it has the zero value or, for arrays and
+// dictionaries, is empty; explicitly set optional booleans count as set.
+// Unmodeled keys kept in a payload's ExtraFields count as set, so e.g. a
+// Restrictions payload is only removed if it has neither modeled keys
+// nor ExtraFields.
+// Payloads of unknown types and unmodeled certificate payloads are never
+// removed as their content is not known.
+func (p *Profile) RemoveEmptyPayloads() int {
+	var kept []payloadWrapper
+	for _, pc := range p.PayloadContent {
+		if !isEmptyPayload(pc.Payload) {
+			kept = append(kept, pc)
+		}
+	}
+	n := len(p.PayloadContent) - len(kept)
+	p.PayloadContent = kept
+	return n
+}
+
+// isEmptyPayload reports whether payload pld has no keys set other than
+// those of the embedded Payload.
+func isEmptyPayload(pld interface{}) bool {
+	switch pld.(type) {
+	case nil, *Payload, *CertificateGenericPayload:
+		return false
+	}
+	v := reflect.ValueOf(pld)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	v = v.Elem()
+	payloadType, bindingType := reflect.TypeOf(Payload{}), reflect.TypeOf(binding{})
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Type().Field(i); f.Anonymous && (f.Type == payloadType || f.Type == bindingType) {
+			continue
+		}
+		if !isEmptyValue(v.Field(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmptyValue reports whether v is the zero value or an empty array or
+// dictionary, recursing into structs.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isEmptyValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,67 @@
+package cfgprofiles
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestProfileRemoveEmptyPayloads(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewWiFiPayload("com.example.profile.wifi.empty"))
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	wifi.SSID = "Example"
+	p.AddPayload(wifi)
+	p.AddPayload(NewSCEPPayload("com.example.profile.scep.empty"))
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadContent.URL = "https://scep.example.com/scep"
+	p.AddPayload(scep)
+	p.AddPayload(NewCustomSettingsPayload("com.example.profile.custom"))
+	autoJoin := false
+	explicit := NewWiFiPayload("com.example.profile.wifi.autojoin")
+	explicit.AutoJoin = &autoJoin
+	p.AddPayload(explicit)
+	p.AddPayload(NewPayload("com.example.unknown", "com.example.profile.unknown"))
+
+	if have, want := p.RemoveEmptyPayloads(), 3; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	var ids []string
+	for _, pc := range p.PayloadContent {
+		ids = append(ids, CommonPayload(pc.Payload).PayloadIdentifier)
+	}
+	want := []string{
+		"com.example.profile.wifi",
+		"com.example.profile.scep",
+		"com.example.profile.wifi.autojoin",
+		"com.example.profile.unknown",
+	}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("have %v, want %v", ids, want)
+	}
+	if have := p.RemoveEmptyPayloads(); have != 0 {
+		t.Errorf("have %d, want 0", have)
+	}
+}
+
+func TestProfileRemoveEmptyPayloadsRestrictions(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "restrictions.mobileconfig"))
+	fatalIf(t, err)
+	p := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p))
+	p.AddPayload(NewRestrictionsPayload("com.example.profile.restrictions.empty"))
+
+	if have, want := p.RemoveEmptyPayloads(), 1; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	pls := p.RestrictionsPayloads()
+	if len(pls) != 1 {
+		t.Fatalf("have %d restrictions payloads, want 1", len(pls))
+	}
+	if have, want := pls[0].PayloadIdentifier, "com.example.profile.restrictions"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
@@ -0,0 +1,132 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/micromdm/plist"
+)
+
+const (
+	plistDocStart = xml.Header + `<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n" + `<plist version="1.0">`
+	plistDocEnd   = "</plist>\n"
+)
+
+// EncodeProfiles writes profiles to w as a property list array of
+// profile dictionaries. Profiles are marshaled and written one at a time
+// so memory use is bounded by the largest profile rather than the whole
+// array. The output is identical to marshaling the slice. Use
+// DecodeProfiles to read it.
+func EncodeProfiles(w io.Writer, profiles []*Profile) error {
+	if _, err := io.WriteString(w, plistDocStart+"<array>"); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := plist.NewEncoder(&buf)
+	xenc := xml.NewEncoder(w)
+	for i, p := range profiles {
+		if p == nil {
+			return fmt.Errorf("profile %d: cannot encode nil profile", i)
+		}
+		buf.Reset()
+		if err := enc.Encode(p); err != nil {
+			return fmt.Errorf("profile %d: %w", i, err)
+		}
+		if err := copyPlistValue(xenc, &buf); err != nil {
+			return fmt.Errorf("profile %d: %w", i, err)
+		}
+	}
+	_, err := io.WriteString(w, "</array>"+plistDocEnd)
+	return err
+}
+
+// copyPlistValue streams the XML tokens of the value in the property list
+// document r to enc, leaving out the document header and the <plist>
+// element.
+func copyPlistValue(enc *xml.Encoder, r io.Reader) error {
+	dec := xml.NewDecoder(r)
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				continue
+			}
+		default:
+			if depth == 0 {
+				continue
+			}
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+	return enc.Flush()
+}
+
+// DecodeProfiles reads a property list array of profile dictionaries,
+// such as written by EncodeProfiles, from r.
+func DecodeProfiles(r io.Reader) (profiles []*Profile, err error) {
+	err = plist.NewDecoder(r).Decode(&profiles)
+	return
+}
+
+// dataElement matches a <data> element.
+var dataElement = regexp.MustCompile(`<data>([^<]*)</data>`)
+
+// WrapDataAt re-wraps the base64 content of the <data> elements of the
+// XML property list b at cols characters per line, as Apple's tooling
+// does, to produce output which diffs cleanly against reference files.
+// Wrapped content is placed on its own lines with the indentation of the
+// <data> element's line. A cols of zero or less places the content
+// inline on a single line.
+func WrapDataAt(b []byte, cols int) []byte {
+	var out bytes.Buffer
+	last := 0
+	for _, m := range dataElement.FindAllSubmatchIndex(b, -1) {
+		out.Write(b[last:m[0]])
+		last = m[1]
+
+		// indentation of the line the element starts on
+		lineStart := bytes.LastIndexByte(b[:m[0]], '\n') + 1
+		indent := b[lineStart:m[0]]
+		indent = indent[:len(indent)-len(bytes.TrimLeft(indent, " \t"))]
+
+		data := bytes.Join(bytes.Fields(b[m[2]:m[3]]), nil)
+		out.WriteString("<data>")
+		if cols <= 0 || len(data) == 0 {
+			out.Write(data)
+		} else {
+			for len(data) > 0 {
+				n := cols
+				if n > len(data) {
+					n = len(data)
+				}
+				out.WriteByte('\n')
+				out.Write(indent)
+				out.Write(data[:n])
+				data = data[n:]
+			}
+			out.WriteByte('\n')
+			out.Write(indent)
+		}
+		out.WriteString("</data>")
+	}
+	out.Write(b[last:])
+	return out.Bytes()
+}
@@ -0,0 +1,128 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func testProfiles(n int) []*Profile {
+	profiles := make([]*Profile, n)
+	for i := range profiles {
+		p := NewProfile(fmt.Sprintf("com.example.profile%d", i))
+		p.PayloadDisplayName = fmt.Sprintf("Profile %d", i)
+		cert := NewCertificatePKCS1Payload(p.PayloadIdentifier + ".cert")
+		cert.PayloadContent = bytes.Repeat([]byte{byte(i)}, 1024)
+		p.AddPayload(cert)
+		p.AddPayload(NewMDMPayload(p.PayloadIdentifier + ".mdm"))
+		profiles[i] = p
+	}
+	return profiles
+}
+
+func TestEncodeProfiles(t *testing.T) {
+	profiles := testProfiles(3)
+	var buf bytes.Buffer
+	fatalIf(t, EncodeProfiles(&buf, profiles))
+
+	want, err := plist.Marshal(profiles)
+	fatalIf(t, err)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("have %s, want %s", buf.Bytes(), want)
+	}
+
+	decoded, err := DecodeProfiles(&buf)
+	fatalIf(t, err)
+	if have, want := len(decoded), len(profiles); have != want {
+		t.Fatalf("have %d profiles, want %d", have, want)
+	}
+	for i := range decoded {
+		if have, want := decoded[i].PayloadUUID, profiles[i].PayloadUUID; have != want {
+			t.Errorf("have %q, want %q", have, want)
+		}
+	}
+
+	buf.Reset()
+	fatalIf(t, EncodeProfiles(&buf, nil))
+	want, err = plist.Marshal([]*Profile{})
+	fatalIf(t, err)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("have %s, want %s", buf.Bytes(), want)
+	}
+
+	if err := EncodeProfiles(io.Discard, []*Profile{nil}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestEncodeProfilesRoundTrip(t *testing.T) {
+	profiles := testProfiles(2)
+	profiles[1].PayloadDescription = "Escaped <&> \"text\"\nacross lines"
+	var buf bytes.Buffer
+	fatalIf(t, EncodeProfiles(&buf, profiles))
+
+	decoded, err := DecodeProfiles(&buf)
+	fatalIf(t, err)
+	if !reflect.DeepEqual(decoded, profiles) {
+		t.Errorf("have %+v, want %+v", decoded, profiles)
+	}
+}
+
+func BenchmarkEncodeProfiles(b *testing.B) {
+	profiles := testProfiles(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := EncodeProfiles(io.Discard, profiles); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalProfiles(b *testing.B) {
+	profiles := testProfiles(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, err := plist.Marshal(profiles)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Discard.Write(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestWrapDataAt(t *testing.T) {
+	pl := NewCertificatePKCS1Payload("com.example.profile.cert")
+	pl.PayloadContent = bytes.Repeat([]byte{0xff}, 30) // 40 base64 characters
+	pl.PayloadUUID = "UUID"
+	b, err := plist.MarshalIndent(pl, "\t")
+	fatalIf(t, err)
+
+	wrapped := WrapDataAt(b, 16)
+	want := "\t<key>PayloadContent</key>\n\t<data>\n\t////////////////\n\t////////////////\n\t////////\n\t</data>\n"
+	if !bytes.Contains(wrapped, []byte(want)) {
+		t.Errorf("have %s, want to contain %q", wrapped, want)
+	}
+
+	pl2 := &CertificatePKCS1Payload{}
+	fatalIf(t, plist.Unmarshal(wrapped, pl2))
+	if !bytes.Equal(pl2.PayloadContent, pl.PayloadContent) {
+		t.Errorf("have %x, want %x", pl2.PayloadContent, pl.PayloadContent)
+	}
+
+	if have := WrapDataAt(wrapped, 0); !bytes.Equal(have, b) {
+		t.Errorf("have %s, want %s", have, b)
+	}
+
+	// several elements on a single line
+	b = []byte("<array><data>AAAA</data><data>BBBB</data></array>")
+	want = "<array><data>\nAA\nAA\n</data><data>\nBB\nBB\n</data></array>"
+	if have := string(WrapDataAt(b, 2)); have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
@@ -0,0 +1,119 @@
+package cfgprofiles
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+
+// EnvelopeRecipient identifies a recipient of an encrypted profile.
+// Recipients are identified either by the issuer and serial number of
+// their certificate or by its subject key identifier.
+type EnvelopeRecipient struct {
+	Issuer       pkix.Name
+	SerialNumber *big.Int
+	SubjectKeyID []byte
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type envelopedData struct {
+	Version              int
+	OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo asn1.RawValue
+	UnprotectedAttrs     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type keyTransRecipientInfo struct {
+	Version                int
+	RecipientIdentifier    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// ProfileEnvelopeRecipients parses the EncryptedPayloadContent of p as a
+// CMS EnvelopedData structure and returns the certificate issuer of each
+// of its key transport recipients without decrypting the content. This
+// can be used to select the private key to decrypt the profile with.
+// Recipients identified by subject key identifier have an empty name; see
+// ProfileEnvelopeRecipientInfos for serial numbers and key identifiers.
+func ProfileEnvelopeRecipients(p *Profile) ([]*pkix.Name, error) {
+	infos, err := ProfileEnvelopeRecipientInfos(p)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]*pkix.Name, 0, len(infos))
+	for i := range infos {
+		names = append(names, &infos[i].Issuer)
+	}
+	return names, nil
+}
+
+// ProfileEnvelopeRecipientInfos is like ProfileEnvelopeRecipients but
+// returns the full recipient identifiers.
+func ProfileEnvelopeRecipientInfos(p *Profile) ([]EnvelopeRecipient, error) {
+	if len(p.EncryptedPayloadContent) == 0 {
+		return nil, errors.New("profile has no encrypted payload content")
+	}
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(p.EncryptedPayloadContent, &ci); err != nil {
+		return nil, fmt.Errorf("parsing content info: %w", err)
+	}
+	if !ci.ContentType.Equal(oidEnvelopedData) {
+		return nil, fmt.Errorf("content type is not enveloped data: %v", ci.ContentType)
+	}
+	var ed envelopedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+		return nil, fmt.Errorf("parsing enveloped data: %w", err)
+	}
+	var recipients []EnvelopeRecipient
+	for _, ri := range ed.RecipientInfos {
+		if ri.Class != asn1.ClassUniversal || ri.Tag != asn1.TagSequence {
+			continue // only key transport recipients are supported
+		}
+		var ktri keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(ri.FullBytes, &ktri); err != nil {
+			return nil, fmt.Errorf("parsing recipient info: %w", err)
+		}
+		r, err := parseRecipientIdentifier(ktri.RecipientIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients, nil
+}
+
+// parseRecipientIdentifier parses either an IssuerAndSerialNumber or a
+// [0] SubjectKeyIdentifier recipient identifier.
+func parseRecipientIdentifier(rid asn1.RawValue) (EnvelopeRecipient, error) {
+	var r EnvelopeRecipient
+	if rid.Class == asn1.ClassContextSpecific && rid.Tag == 0 {
+		r.SubjectKeyID = rid.Bytes
+		return r, nil
+	}
+	var ias issuerAndSerialNumber
+	if _, err := asn1.Unmarshal(rid.FullBytes, &ias); err != nil {
+		return r, fmt.Errorf("parsing recipient identifier: %w", err)
+	}
+	var rdns pkix.RDNSequence
+	if _, err := asn1.Unmarshal(ias.Issuer.FullBytes, &rdns); err != nil {
+		return r, fmt.Errorf("parsing recipient issuer: %w", err)
+	}
+	r.Issuer.FillFromRDNSequence(&rdns)
+	r.SerialNumber = ias.SerialNumber
+	return r, nil
+}
@@ -0,0 +1,70 @@
+package cfgprofiles
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestProfileEnvelopeRecipients(t *testing.T) {
+	cert := GetCertData(t)
+
+	ktri, err := asn1.Marshal(keyTransRecipientInfo{
+		RecipientIdentifier: asn1.RawValue{FullBytes: mustMarshal(t, issuerAndSerialNumber{
+			Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+			SerialNumber: cert.SerialNumber,
+		})},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}},
+		EncryptedKey:           []byte{0x01, 0x02},
+	})
+	fatalIf(t, err)
+	ed := mustMarshal(t, struct {
+		Version              int
+		RecipientInfos       []asn1.RawValue `asn1:"set"`
+		EncryptedContentInfo asn1.RawValue
+	}{
+		RecipientInfos:       []asn1.RawValue{{FullBytes: ktri}},
+		EncryptedContentInfo: asn1.RawValue{FullBytes: mustMarshal(t, asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1})},
+	})
+	ci := mustMarshal(t, struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: oidEnvelopedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: ed},
+	})
+
+	p := NewProfile("com.example.profile")
+	p.EncryptedPayloadContent = ci
+	names, err := ProfileEnvelopeRecipients(p)
+	fatalIf(t, err)
+	if len(names) != 1 {
+		t.Fatalf("have %d recipients, want %d", len(names), 1)
+	}
+	if have, want := names[0].CommonName, cert.Issuer.CommonName; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	recipients, err := ProfileEnvelopeRecipientInfos(p)
+	fatalIf(t, err)
+	if len(recipients) != 1 {
+		t.Fatalf("have %d recipients, want %d", len(recipients), 1)
+	}
+	if have, want := recipients[0].Issuer.CommonName, cert.Issuer.CommonName; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if recipients[0].SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("have %v, want %v", recipients[0].SerialNumber, cert.SerialNumber)
+	}
+
+	p.EncryptedPayloadContent = []byte{0x30, 0x01}
+	if _, err := ProfileEnvelopeRecipients(p); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := asn1.Marshal(v)
+	fatalIf(t, err)
+	return b
+}
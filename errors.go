@@ -0,0 +1,71 @@
+package cfgprofiles
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// Errors is a list of errors. It is returned when several independent
+// errors are found, for example during strict parsing.
+type Errors []error
+
+// Error joins the messages of all errors.
+func (e Errors) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns the list of errors for use with errors.Is and errors.As.
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// errOrNil returns nil if e is empty, the only error if e contains one
+// error, and e otherwise.
+func (e Errors) errOrNil() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	default:
+		return e
+	}
+}
+
+// UnknownPayloadTypeError is returned by strict parsing when a payload's
+// PayloadType does not match any specific payload struct.
+type UnknownPayloadTypeError struct {
+	PayloadType string
+	Index       int // index of the payload in the profile's PayloadContent
+}
+
+func (e *UnknownPayloadTypeError) Error() string {
+	return fmt.Sprintf("unknown PayloadType %q at PayloadContent index %d", e.PayloadType, e.Index)
+}
+
+// PayloadTypeMismatchError is returned when marshaling a payload whose
+// PayloadType does not match the PayloadType of its payload struct.
+type PayloadTypeMismatchError struct {
+	PayloadType  string // PayloadType of the payload
+	ExpectedType string // PayloadType of the payload struct
+}
+
+func (e *PayloadTypeMismatchError) Error() string {
+	return fmt.Sprintf("PayloadType %q does not match payload struct type %q", e.PayloadType, e.ExpectedType)
+}
+
+// SignatureContentMismatchError is returned by VerifyProfileSignature when
+// the signature is valid but the profile does not match the signed
+// content, for example because it was modified after being unwrapped.
+type SignatureContentMismatchError struct {
+	Signer *x509.Certificate
+}
+
+func (e *SignatureContentMismatchError) Error() string {
+	return "profile does not match the signed content"
+}
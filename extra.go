@@ -0,0 +1,77 @@
+package cfgprofiles
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/micromdm/plist"
+)
+
+// marshalWithExtra marshals v, a pointer to a struct without custom
+// marshalling, to a dictionary and adds the entries of extra. It is an
+// error for extra to contain a key set by one of v's fields. Keys of
+// unset fields are allowed so that extra fields keep working once their
+// key is modeled.
+func marshalWithExtra(v interface{}, extra map[string]interface{}) (interface{}, error) {
+	// round-trip the fields through a dict to respect their tags
+	b, err := plist.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{})
+	if err := plist.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	for k, ev := range extra {
+		if _, ok := m[k]; ok {
+			return nil, fmt.Errorf("extra field %q conflicts with a named field", k)
+		}
+		m[k] = ev
+	}
+	return m, nil
+}
+
+// unmarshalExtra unmarshals v, a pointer to a struct without custom
+// unmarshalling, and returns the dictionary entries which do not belong
+// to any of its fields or nil if there are none.
+func unmarshalExtra(f func(interface{}) error, v interface{}) (map[string]interface{}, error) {
+	if err := f(v); err != nil {
+		return nil, err
+	}
+	var all map[string]interface{}
+	if err := f(&all); err != nil {
+		return nil, err
+	}
+	known := plistKeys(reflect.TypeOf(v).Elem())
+	var extra map[string]interface{}
+	for k, ev := range all {
+		if known[k] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]interface{})
+		}
+		extra[k] = ev
+	}
+	return extra, nil
+}
+
+// plistKeys returns the property list keys of the fields of struct type
+// t including those of embedded structs.
+func plistKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("plist") == "-" || f.PkgPath != "" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for k := range plistKeys(f.Type) {
+				keys[k] = true
+			}
+			continue
+		}
+		keys[plistKey(f)] = true
+	}
+	return keys
+}
@@ -0,0 +1,74 @@
+package cfgprofiles
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// FDERecoveryKeyEscrowPayload represents the "com.apple.security.FDERecoveryKeyEscrow" PayloadType.
+// It redirects FileVault personal recovery keys to be escrowed, encrypted
+// to the certificate payload referenced by EncryptCertPayloadUUID.
+// See https://developer.apple.com/documentation/devicemanagement/fderecoverykeyescrow
+type FDERecoveryKeyEscrowPayload struct {
+	Payload
+	binding
+	Location               string
+	EncryptCertPayloadUUID string
+	DeviceInfoURL          string `plist:",omitempty"`
+}
+
+// NewFDERecoveryKeyEscrowPayload creates a new payload with identifier i
+func NewFDERecoveryKeyEscrowPayload(i string) *FDERecoveryKeyEscrowPayload {
+	return &FDERecoveryKeyEscrowPayload{
+		Payload: *NewPayload(PayloadTypeFDERecoveryKeyEscrow, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeFDERecoveryKeyEscrow.
+func (pl *FDERecoveryKeyEscrowPayload) ExpectedPayloadType() string {
+	return PayloadTypeFDERecoveryKeyEscrow
+}
+
+// Validate checks that the required keys are set.
+func (pl *FDERecoveryKeyEscrowPayload) Validate() error {
+	if pl.Location == "" {
+		return errors.New("empty Location")
+	}
+	if pl.EncryptCertPayloadUUID == "" {
+		return errors.New("empty EncryptCertPayloadUUID")
+	}
+	return nil
+}
+
+// validateProfile checks that EncryptCertPayloadUUID refers to a
+// certificate payload in profile p.
+func (pl *FDERecoveryKeyEscrowPayload) validateProfile(p *Profile) error {
+	if pl.EncryptCertPayloadUUID == "" {
+		return nil
+	}
+	pld := p.PayloadByUUID(pl.EncryptCertPayloadUUID)
+	if pld == nil {
+		return fmt.Errorf("EncryptCertPayloadUUID %q: no payload with UUID", pl.EncryptCertPayloadUUID)
+	}
+	if !isCertificatePayload(pld) {
+		return fmt.Errorf("EncryptCertPayloadUUID %q: %T is not a certificate payload", pl.EncryptCertPayloadUUID, pld)
+	}
+	return nil
+}
+
+// Certificate resolves EncryptCertPayloadUUID to a certificate payload in
+// profile p and returns its parsed certificate.
+func (pl *FDERecoveryKeyEscrowPayload) Certificate(p *Profile) (*x509.Certificate, error) {
+	return resolveCertificate(p, pl.EncryptCertPayloadUUID)
+}
+
+// FDERecoveryKeyEscrowPayloads returns a slice of all payloads of that type
+func (p *Profile) FDERecoveryKeyEscrowPayloads() (plds []*FDERecoveryKeyEscrowPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*FDERecoveryKeyEscrowPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package cfgprofiles
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestFDERecoveryKeyEscrowPayload(t *testing.T) {
+	cert := GetCertData(t)
+	p := NewProfile("com.example.profile")
+	uuids := p.AddCertificateChain([]*x509.Certificate{cert})
+	pl := NewFDERecoveryKeyEscrowPayload("com.example.profile.fde")
+	p.AddPayload(pl)
+
+	if err := p.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pl.Location = "Example MDM"
+	pl.EncryptCertPayloadUUID = "missing"
+	if err := p.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pl.EncryptCertPayloadUUID = uuids[0]
+	fatalIf(t, p.Validate())
+	c, err := pl.Certificate(p)
+	fatalIf(t, err)
+	if !c.Equal(cert) {
+		t.Error("expected certificate to match")
+	}
+
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.FDERecoveryKeyEscrowPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if have, want := pls[0].EncryptCertPayloadUUID, uuids[0]; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	p2.RegenerateUUIDs()
+	fatalIf(t, p2.Validate())
+}
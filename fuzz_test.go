@@ -0,0 +1,53 @@
+package cfgprofiles
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func FuzzParseProfile(f *testing.F) {
+	matches, err := filepath.Glob(filepath.Join("testdata", "*.mobileconfig"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, m := range matches {
+		b, err := ioutil.ReadFile(m)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		// the strict decoder must not panic either
+		UnmarshalStrict(b, &Profile{})
+
+		p, err := ParseProfile(b)
+		if err != nil {
+			return
+		}
+		// successfully parsed profiles must marshal without panicking
+		plist.Marshal(p)
+	})
+}
+
+func FuzzVerifyProfileSignature(f *testing.F) {
+	matches, err := filepath.Glob(filepath.Join("testdata", "signed-*.mobileconfig"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, m := range matches {
+		b, err := ioutil.ReadFile(m)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		// must not panic; errors are expected
+		VerifyProfileSignature(b, nil)
+		ProfileSigners(b)
+	})
+}
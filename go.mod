@@ -1,8 +1,12 @@
 module github.com/jessepeterson/cfgprofiles
 
-go 1.13
+go 1.20
 
 require (
 	github.com/google/uuid v1.6.0
 	github.com/micromdm/plist v0.2.0
+	github.com/smallstep/pkcs7 v0.2.3
+	software.sslmate.com/src/go-pkcs12 v0.7.3
 )
+
+require golang.org/x/crypto v0.33.0 // indirect
@@ -0,0 +1,94 @@
+package cfgprofiles
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lint warning codes.
+const (
+	WarningMissingDisplayName  = "missing-display-name"
+	WarningMissingOrganization = "missing-organization"
+	WarningLowercaseUUID       = "lowercase-uuid"
+	WarningPlaintextChallenge  = "plaintext-challenge"
+	WarningDevelopmentAPNS     = "development-apns"
+	WarningLongExpiration      = "long-expiration"
+	WarningAllAppsKeyAccess    = "all-apps-key-access"
+)
+
+// lintMaxExpiration is the longest PayloadExpirationDate, relative to the
+// PayloadDate, which Lint does not warn about.
+const lintMaxExpiration = 2 * 365 * 24 * time.Hour
+
+// Warning is a best-practice issue found by Lint. Unlike validation
+// errors warnings do not make a profile invalid.
+type Warning struct {
+	Code        string // one of the Warning* constants
+	Message     string
+	PayloadUUID string // UUID of the profile or payload the warning is for
+}
+
+// String formats the warning for display.
+func (w Warning) String() string {
+	return fmt.Sprintf("%s (%s): %s", w.Code, w.PayloadUUID, w.Message)
+}
+
+// Lint checks the profile and its payloads for best-practice issues
+// such as missing display names, plaintext SCEP challenges, the
+// development APNs environment, lowercase UUIDs, a missing organization,
+// SCEP keys which are not extractable but accessible to all apps and an
+// expiration date more than two years after the profile date (or
+// now). Use Validate to check for invalid profiles.
+func (p *Profile) Lint() (warnings []Warning) {
+	warn := func(code, u, format string, args ...interface{}) {
+		warnings = append(warnings, Warning{Code: code, Message: fmt.Sprintf(format, args...), PayloadUUID: u})
+	}
+
+	if p.PayloadDisplayName == "" {
+		warn(WarningMissingDisplayName, p.PayloadUUID, "profile has no PayloadDisplayName")
+	}
+	if p.PayloadOrganization == "" {
+		warn(WarningMissingOrganization, p.PayloadUUID, "profile has no PayloadOrganization")
+	}
+	if p.PayloadUUID != strings.ToUpper(p.PayloadUUID) {
+		warn(WarningLowercaseUUID, p.PayloadUUID, "profile PayloadUUID is not uppercase")
+	}
+	if p.PayloadExpirationDate != nil {
+		from := time.Now()
+		if p.PayloadDate != nil {
+			from = *p.PayloadDate
+		}
+		if p.PayloadExpirationDate.Sub(from) > lintMaxExpiration {
+			warn(WarningLongExpiration, p.PayloadUUID, "PayloadExpirationDate is more than two years away")
+		}
+	}
+
+	for i, pc := range p.PayloadContent {
+		pld := CommonPayload(pc.Payload)
+		if pld == nil {
+			continue
+		}
+		u := pld.PayloadUUID
+		if pld.PayloadDisplayName == "" {
+			warn(WarningMissingDisplayName, u, "payload %d (%s) has no PayloadDisplayName", i, pld.PayloadType)
+		}
+		if u != strings.ToUpper(u) {
+			warn(WarningLowercaseUUID, u, "payload %d PayloadUUID is not uppercase", i)
+		}
+		switch pl := pc.Payload.(type) {
+		case *SCEPPayload:
+			if pl.PayloadContent.Challenge != "" {
+				warn(WarningPlaintextChallenge, u, "SCEP challenge is stored in plaintext; consider a dynamic challenge or encrypting the profile")
+			}
+			if ke := pl.PayloadContent.KeyIsExtractable; pl.PayloadContent.AllowAllAppsAccess && ke != nil && !*ke {
+				warn(WarningAllAppsKeyAccess, u, "AllowAllAppsAccess gives all apps access to a non-extractable private key")
+			}
+		case *MDMPayload:
+			if pl.UseDevelopmentAPNS {
+				warn(WarningDevelopmentAPNS, u, "UseDevelopmentAPNS is set; production devices cannot receive development APNs pushes")
+			}
+		}
+	}
+	return
+}
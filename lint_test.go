@@ -0,0 +1,96 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileLint(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.PayloadDisplayName = "Example"
+	p.PayloadOrganization = "Example Org"
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	mdm.PayloadDisplayName = "MDM"
+	p.AddPayload(mdm)
+	if w := p.Lint(); len(w) != 0 {
+		t.Errorf("expected no warnings, have %v", w)
+	}
+
+	p.PayloadOrganization = ""
+	p.PayloadUUID = strings.ToLower(p.PayloadUUID)
+	now := time.Now()
+	expires := now.Add(3 * 365 * 24 * time.Hour)
+	p.PayloadDate, p.PayloadExpirationDate = &now, &expires
+	mdm.SetDevelopmentAPNS()
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadContent.Challenge = "s3cret"
+	p.AddPayload(scep)
+
+	var codes []string
+	for _, w := range p.Lint() {
+		codes = append(codes, w.Code)
+	}
+	want := []string{
+		WarningMissingOrganization,
+		WarningLowercaseUUID,
+		WarningLongExpiration,
+		WarningDevelopmentAPNS,
+		WarningMissingDisplayName,
+		WarningPlaintextChallenge,
+	}
+	if !reflect.DeepEqual(codes, want) {
+		t.Errorf("have %v, want %v", codes, want)
+	}
+}
+
+func TestProfileLintDevelopmentAPNS(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	p.AddPayload(mdm)
+	hasWarning := func() bool {
+		for _, w := range p.Lint() {
+			if w.Code == WarningDevelopmentAPNS {
+				return true
+			}
+		}
+		return false
+	}
+
+	mdm.SetDevelopmentAPNS()
+	if !hasWarning() {
+		t.Error("expected development APNs warning")
+	}
+	mdm.SetProductionAPNS()
+	if mdm.UseDevelopmentAPNS || hasWarning() {
+		t.Error("expected production APNs")
+	}
+}
+
+func TestProfileLintAllAppsKeyAccess(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	hasWarning := func() bool {
+		for _, w := range p.Lint() {
+			if w.Code == WarningAllAppsKeyAccess {
+				return true
+			}
+		}
+		return false
+	}
+
+	scep.PayloadContent.AllowAllApps(true)
+	if hasWarning() {
+		t.Error("expected no warning for the default extractable key")
+	}
+	scep.PayloadContent.SetKeyExtractable(false)
+	if !hasWarning() {
+		t.Error("expected all apps key access warning")
+	}
+	scep.PayloadContent.AllowAllApps(false)
+	if hasWarning() {
+		t.Error("expected no warning")
+	}
+}
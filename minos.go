@@ -0,0 +1,79 @@
+package cfgprofiles
+
+import (
+	"strconv"
+	"strings"
+)
+
+// osVersions are minimum iOS and macOS versions. An empty version means
+// no known minimum.
+type osVersions struct {
+	iOS   string
+	macOS string
+}
+
+// payloadTypeMinimumOS are the minimum OS versions which support each
+// PayloadType. PayloadTypes supported since the introduction of profiles
+// are not listed.
+var payloadTypeMinimumOS = map[string]osVersions{
+	PayloadTypeACME:                     {"16.0", "13.0"},
+	PayloadTypeCertificateTransparency:  {"12.2", "10.14.4"},
+	PayloadTypeExtensibleSSO:            {"13.0", "10.15"},
+	PayloadTypeFDERecoveryKeyEscrow:     {"", "10.13"},
+	PayloadTypePPPC:                     {"", "10.14"},
+	PayloadTypeSetupAssistant:           {"", "10.15"},
+	"com.apple.system-extension-policy": {"", "10.15"},
+}
+
+// MinimumOS returns the highest minimum iOS and macOS versions required
+// by the PayloadTypes of the profile's payloads. An empty version means
+// no payload has a known minimum for that platform. Whether a payload
+// type is available on a platform at all is not considered.
+func (p *Profile) MinimumOS() (ios, macos string) {
+	for _, t := range p.PayloadTypes() {
+		v, ok := payloadTypeMinimumOS[t]
+		if !ok {
+			continue
+		}
+		if compareVersions(v.iOS, ios) > 0 {
+			ios = v.iOS
+		}
+		if compareVersions(v.macOS, macos) > 0 {
+			macos = v.macOS
+		}
+	}
+	return
+}
+
+// compareVersions compares dotted version strings a and b numerically
+// and returns -1, 0 or 1. Missing components are treated as zero so that
+// "10.15" equals "10.15.0". An empty version is lower than any other.
+func compareVersions(a, b string) int {
+	if a == "" || b == "" {
+		switch {
+		case a == b:
+			return 0
+		case a == "":
+			return -1
+		default:
+			return 1
+		}
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,40 @@
+package cfgprofiles
+
+import "testing"
+
+func TestProfileMinimumOS(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	ios, macos := p.MinimumOS()
+	if ios != "" || macos != "" {
+		t.Errorf("have %q, %q, want no minimums", ios, macos)
+	}
+
+	p.AddPayload(NewExtensibleSSOPayload("com.example.profile.sso"))
+	p.AddPayload(NewFDERecoveryKeyEscrowPayload("com.example.profile.fde"))
+	p.AddPayload(NewACMECertificatePayload("com.example.profile.acme"))
+	ios, macos = p.MinimumOS()
+	if have, want := ios, "16.0"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := macos, "13.0"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"10.15", "10.15.0", 0},
+		{"10.14.4", "10.15", -1},
+		{"13.0", "10.15", 1},
+		{"", "4.0", -1},
+		{"", "", 0},
+	} {
+		if have := compareVersions(tt.a, tt.b); have != tt.want {
+			t.Errorf("compareVersions(%q, %q): have %d, want %d", tt.a, tt.b, have, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package cfgprofiles
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidateOID checks that s is a dotted decimal object identifier such
+// as "1.3.6.1.5.5.7.3.2". It must have at least two arcs, the first of
+// which is 0, 1 or 2, and every arc must be a decimal number without
+// leading zeros.
+func ValidateOID(s string) error {
+	if s == "" {
+		return errors.New("empty OID")
+	}
+	arcs := strings.Split(s, ".")
+	if len(arcs) < 2 {
+		return fmt.Errorf("OID %q has fewer than two arcs", s)
+	}
+	for _, arc := range arcs {
+		if arc == "" {
+			return fmt.Errorf("OID %q has an empty arc", s)
+		}
+		for _, c := range arc {
+			if c < '0' || c > '9' {
+				return fmt.Errorf("OID %q has a non-numeric arc: %q", s, arc)
+			}
+		}
+		if len(arc) > 1 && arc[0] == '0' {
+			return fmt.Errorf("OID %q has an arc with a leading zero: %q", s, arc)
+		}
+	}
+	if arcs[0] != "0" && arcs[0] != "1" && arcs[0] != "2" {
+		return fmt.Errorf("OID %q has an invalid first arc", s)
+	}
+	return nil
+}
+
+// validateSubjectOIDs checks the attribute types of a SCEP or ACME
+// payload Subject which are given as OIDs. Types starting with a digit
+// are taken to be OIDs; others are short names such as "CN".
+func validateSubjectOIDs(subject [][][]string) error {
+	for _, rdn := range SubjectToRDNSequence(subject) {
+		if rdn.Type != "" && rdn.Type[0] >= '0' && rdn.Type[0] <= '9' {
+			if err := ValidateOID(rdn.Type); err != nil {
+				return fmt.Errorf("invalid Subject attribute type: %w", err)
+			}
+		}
+	}
+	return nil
+}
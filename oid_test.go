@@ -0,0 +1,58 @@
+package cfgprofiles
+
+import "testing"
+
+func TestValidateOID(t *testing.T) {
+	for _, tt := range []struct {
+		oid   string
+		valid bool
+	}{
+		{"1.3.6.1.5.5.7.3.2", true},
+		{"2.5.4.3", true},
+		{"0.9.2342.19200300.100.1.1", true},
+		{"", false},
+		{"1", false},
+		{"1..2", false},
+		{"1.2.", false},
+		{".1.2", false},
+		{"1.2.a", false},
+		{"1.2.-3", false},
+		{"1.02", false},
+		{"3.1", false},
+		{"CN", false},
+	} {
+		err := ValidateOID(tt.oid)
+		if tt.valid && err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.oid, err)
+		} else if !tt.valid && err == nil {
+			t.Errorf("%q: expected an error", tt.oid)
+		}
+	}
+}
+
+func TestPayloadOIDValidation(t *testing.T) {
+	acme := NewACMECertificatePayload("com.example.profile.acme")
+	acme.ExtendedKeyUsage = []string{"1.3.6.1.5.5.7.3.2"}
+	acme.Subject = [][][]string{{{"CN", "device"}}, {{"2.5.4.5", "serial"}}}
+	fatalIf(t, acme.Validate())
+
+	acme.ExtendedKeyUsage = append(acme.ExtendedKeyUsage, "clientAuth")
+	if err := acme.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+	acme.ExtendedKeyUsage = nil
+	acme.Subject = [][][]string{{{"2..5", "serial"}}}
+	if err := acme.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadContent.SubjectAltName = &SubjectAltName{
+		OtherNames: map[string]MultiString{"1.3.6.1.4.1.311.20.2.3": {"user@example.com"}},
+	}
+	fatalIf(t, scep.Validate())
+	scep.PayloadContent.SubjectAltName.OtherNames["upn"] = MultiString{"user@example.com"}
+	if err := scep.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
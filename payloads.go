@@ -1,15 +1,61 @@
 package cfgprofiles
 
 import (
+	"bytes"
+	"crypto"
+	_ "crypto/sha1" // CA fingerprint hashes
+	_ "crypto/sha256"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net/mail"
+	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/micromdm/plist"
 )
 
+// PayloadType values of the profile and the payloads supported by this package.
+const (
+	PayloadTypeConfiguration           = "Configuration"
+	PayloadTypeCertificatePKCS1        = "com.apple.security.pkcs1"
+	PayloadTypeCertificateRoot         = "com.apple.security.root"
+	PayloadTypeCertificatePEM          = "com.apple.security.pem"
+	PayloadTypeSCEP                    = "com.apple.security.scep"
+	PayloadTypeACME                    = "com.apple.security.acme"
+	PayloadTypeMDM                     = "com.apple.mdm"
+	PayloadTypeWiFi                    = "com.apple.wifi.managed"
+	PayloadTypeVPN                     = "com.apple.vpn.managed"
+	PayloadTypeRestrictions            = "com.apple.applicationaccess"
+	PayloadTypeUniversalAccess         = "com.apple.universalaccess"
+	PayloadTypeFinder                  = "com.apple.finder"
+	PayloadTypeSetupAssistant          = "com.apple.SetupAssistant.managed"
+	PayloadTypeAppConfig               = "com.apple.appconfig"
+	PayloadTypeCertificateTransparency = "com.apple.security.certificatetransparency"
+	PayloadTypeCustomSettings          = "com.apple.ManagedClient.preferences"
+	PayloadTypeManagedPreferences      = "com.apple.defaults"
+	PayloadTypeProfileRemovalPassword  = "com.apple.profileRemovalPassword"
+	PayloadTypeGlobalHTTPProxy         = "com.apple.proxy.http.global"
+	PayloadTypeApplicationAccess       = "com.apple.applicationaccess.new"
+	PayloadTypeFDERecoveryKeyEscrow    = "com.apple.security.FDERecoveryKeyEscrow"
+	PayloadTypeExtensibleSSO           = "com.apple.extensiblesso"
+	PayloadTypeCertificatePKCS12       = "com.apple.security.pkcs12"
+	PayloadTypePPPC                    = "com.apple.TCC.configuration-profile-policy"
+)
+
+// OnUnknown, if not nil, is called when unmarshaling encounters
+// something this package does not model. For a payload with an
+// unrecognized PayloadType it is called with a context of "PayloadType"
+// and the PayloadType as key; such payloads only keep their common
+// payload keys (see [Payload]). It is not safe to change concurrently
+// with unmarshaling.
+var OnUnknown func(context, key string)
+
 // payloadWrapper is a wrapper around a profile payload struct.
 // It exists to implement custom Plist marshal/unmarshal logic required
 // for correctly parsing arbitrary profile payloads in a profile.
@@ -27,7 +73,18 @@ func (p *payloadWrapper) UnmarshalPlist(f func(interface{}) error) error {
 		return err
 	}
 	plStruct := newPayloadForType(plType.PayloadType)
-	err = f(plStruct)
+	if _, ok := plStruct.(*Payload); ok && OnUnknown != nil {
+		OnUnknown("PayloadType", plType.PayloadType)
+	}
+	if _, ok := plStruct.(*Payload); ok && isCertificatePayloadType(plType.PayloadType) {
+		// keep the certificate of unmodeled certificate payload types
+		certStruct := &CertificateGenericPayload{}
+		if err := unmarshalPayloadVersion(f, certStruct); err == nil && len(certStruct.PayloadContent) > 0 {
+			p.Payload = certStruct
+			return nil
+		}
+	}
+	err = unmarshalPayloadVersion(f, plStruct)
 	if err != nil {
 		return err
 	}
@@ -35,22 +92,107 @@ func (p *payloadWrapper) UnmarshalPlist(f func(interface{}) error) error {
 	return nil
 }
 
+// expectedPayloadTyper is implemented by payload structs of a single
+// PayloadType.
+type expectedPayloadTyper interface {
+	ExpectedPayloadType() string
+}
+
 // MarshalPlist returns the wrapped payload struct to marshal.
+// It is an error for the PayloadType of the payload not to match its
+// payload struct.
 func (p *payloadWrapper) MarshalPlist() (interface{}, error) {
+	if p.Payload == nil {
+		return nil, errors.New("cannot marshal nil payload")
+	}
+	if v := reflect.ValueOf(p.Payload); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, fmt.Errorf("cannot marshal nil %T payload", p.Payload)
+	}
+	if e, ok := p.Payload.(expectedPayloadTyper); ok {
+		if c := CommonPayload(p.Payload); c != nil && c.PayloadType != e.ExpectedPayloadType() {
+			return nil, &PayloadTypeMismatchError{
+				PayloadType:  c.PayloadType,
+				ExpectedType: e.ExpectedPayloadType(),
+			}
+		}
+	}
 	return p.Payload, nil
 }
 
+// ParsePayload unmarshals a single payload dictionary in b which is not
+// wrapped in a profile. The returned value is a pointer to the payload
+// struct matching its PayloadType, or a *Payload for unknown types.
+func ParsePayload(b []byte) (pld interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pld, err = nil, fmt.Errorf("malformed payload: %v", r)
+		}
+	}()
+	var w payloadWrapper
+	if err := plist.Unmarshal(b, &w); err != nil {
+		return nil, err
+	}
+	if c := CommonPayload(w.Payload); c == nil || c.PayloadType == "" {
+		return nil, errors.New("missing PayloadType")
+	}
+	return w.Payload, nil
+}
+
+// MarshalPayload marshals pld as a single, indented payload dictionary
+// which is not wrapped in a profile. It is the inverse of ParsePayload.
+func MarshalPayload(pld interface{}) ([]byte, error) {
+	return plist.MarshalIndent(&payloadWrapper{Payload: pld}, "\t")
+}
+
 // newPayloadForType instantiates an empty payload struct given PayloadType t.
 func newPayloadForType(t string) interface{} {
 	switch t {
-	case "com.apple.security.pkcs1":
+	case PayloadTypeCertificatePKCS1:
 		return &CertificatePKCS1Payload{}
-	case "com.apple.mdm":
+	case PayloadTypeMDM:
 		return &MDMPayload{}
-	case "com.apple.security.scep":
+	case PayloadTypeSCEP:
 		return &SCEPPayload{}
-	case "com.apple.security.acme":
+	case PayloadTypeACME:
 		return &ACMECertificatePayload{}
+	case PayloadTypeWiFi:
+		return &WiFiPayload{}
+	case PayloadTypeRestrictions:
+		return &RestrictionsPayload{}
+	case PayloadTypeUniversalAccess:
+		return &UniversalAccessPayload{}
+	case PayloadTypeFinder:
+		return &FinderPayload{}
+	case PayloadTypeSetupAssistant:
+		return &SetupAssistantPayload{}
+	case PayloadTypeAppConfig:
+		return &AppConfigPayload{}
+	case PayloadTypeCertificateRoot:
+		return &CertificateRootPayload{}
+	case PayloadTypeCertificatePEM:
+		return &CertificatePEMPayload{}
+	case PayloadTypeCertificateTransparency:
+		return &CertificateTransparencyPayload{}
+	case PayloadTypeCustomSettings:
+		return &CustomSettingsPayload{}
+	case PayloadTypeManagedPreferences:
+		return &ManagedPreferencesPayload{}
+	case PayloadTypeVPN:
+		return &VPNPayload{}
+	case PayloadTypeProfileRemovalPassword:
+		return &ProfileRemovalPasswordPayload{}
+	case PayloadTypeGlobalHTTPProxy:
+		return &GlobalHTTPProxyPayload{}
+	case PayloadTypeApplicationAccess:
+		return &ApplicationAccessPayload{}
+	case PayloadTypeFDERecoveryKeyEscrow:
+		return &FDERecoveryKeyEscrowPayload{}
+	case PayloadTypeExtensibleSSO:
+		return &ExtensibleSSOPayload{}
+	case PayloadTypeCertificatePKCS12:
+		return &CertificatePKCS12Payload{}
+	case PayloadTypePPPC:
+		return &PPPCPayload{}
 	default:
 		return &Payload{}
 	}
@@ -72,12 +214,25 @@ type Payload struct {
 func NewPayload(t, i string) *Payload {
 	return &Payload{
 		PayloadIdentifier: i,
-		PayloadUUID:       strings.ToUpper(uuid.New().String()),
+		PayloadUUID:       newUUID(),
 		PayloadType:       t,
 		PayloadVersion:    1,
 	}
 }
 
+// UUIDFunc generates the PayloadUUIDs of new payloads and profiles and
+// those assigned by RegenerateUUIDs. It defaults to random uppercase
+// UUIDs and may be replaced, e.g. for deterministic tests or
+// reproducible builds. It is not safe to change concurrently with its use.
+var UUIDFunc = func() string {
+	return strings.ToUpper(uuid.New().String())
+}
+
+// newUUID returns a new UUID from UUIDFunc.
+func newUUID() string {
+	return UUIDFunc()
+}
+
 // CommonPayload returns the common Payload struct of a profile payload i or returns nil.
 func CommonPayload(i interface{}) *Payload {
 	switch pl := i.(type) {
@@ -89,6 +244,46 @@ func CommonPayload(i interface{}) *Payload {
 		return &pl.Payload
 	case *MDMPayload:
 		return &pl.Payload
+	case *WiFiPayload:
+		return &pl.Payload
+	case *RestrictionsPayload:
+		return &pl.Payload
+	case *UniversalAccessPayload:
+		return &pl.Payload
+	case *FinderPayload:
+		return &pl.Payload
+	case *SetupAssistantPayload:
+		return &pl.Payload
+	case *AppConfigPayload:
+		return &pl.Payload
+	case *CertificateRootPayload:
+		return &pl.Payload
+	case *CertificatePEMPayload:
+		return &pl.Payload
+	case *CertificateTransparencyPayload:
+		return &pl.Payload
+	case *CustomSettingsPayload:
+		return &pl.Payload
+	case *ManagedPreferencesPayload:
+		return &pl.Payload
+	case *VPNPayload:
+		return &pl.Payload
+	case *ProfileRemovalPasswordPayload:
+		return &pl.Payload
+	case *GlobalHTTPProxyPayload:
+		return &pl.Payload
+	case *ApplicationAccessPayload:
+		return &pl.Payload
+	case *FDERecoveryKeyEscrowPayload:
+		return &pl.Payload
+	case *ExtensibleSSOPayload:
+		return &pl.Payload
+	case *CertificatePKCS12Payload:
+		return &pl.Payload
+	case *CertificateGenericPayload:
+		return &pl.Payload
+	case *PPPCPayload:
+		return &pl.Payload
 	case *Payload:
 		return pl
 	default:
@@ -117,10 +312,15 @@ type CertificatePKCS1Payload struct {
 // NewCertificatePKCS1Payload creates a new payload with identifier i
 func NewCertificatePKCS1Payload(i string) *CertificatePKCS1Payload {
 	return &CertificatePKCS1Payload{
-		Payload: *NewPayload("com.apple.security.pkcs1", i),
+		Payload: *NewPayload(PayloadTypeCertificatePKCS1, i),
 	}
 }
 
+// ExpectedPayloadType returns PayloadTypeCertificatePKCS1.
+func (pl *CertificatePKCS1Payload) ExpectedPayloadType() string {
+	return PayloadTypeCertificatePKCS1
+}
+
 // CertificatePKCS1Payloads returns a slice of all payloads of that type
 func (p *Profile) CertificatePKCS1Payloads() (plds []*CertificatePKCS1Payload) {
 	for _, pc := range p.PayloadContent {
@@ -131,8 +331,183 @@ func (p *Profile) CertificatePKCS1Payloads() (plds []*CertificatePKCS1Payload) {
 	return
 }
 
+// Validate checks that PayloadContent contains a DER encoded certificate.
+func (pl *CertificatePKCS1Payload) Validate() error {
+	if _, err := pl.Certificate(); err != nil {
+		return fmt.Errorf("invalid PKCS1 certificate payload content: %w", err)
+	}
+	return nil
+}
+
+// CertificatePKCS12Payload represents the "com.apple.security.pkcs12" PayloadType.
+// PayloadContent contains a PKCS #12 identity which is decrypted with
+// Password. If Password is empty the user is prompted for it.
+// See https://developer.apple.com/documentation/devicemanagement/certificatepkcs12
+type CertificatePKCS12Payload struct {
+	Payload
+	PayloadCertificateFileName string `plist:",omitempty"`
+	PayloadContent             []byte
+	Password                   string `plist:",omitempty"`
+}
+
+// NewCertificatePKCS12Payload creates a new payload with identifier i
+func NewCertificatePKCS12Payload(i string) *CertificatePKCS12Payload {
+	return &CertificatePKCS12Payload{
+		Payload: *NewPayload(PayloadTypeCertificatePKCS12, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeCertificatePKCS12.
+func (pl *CertificatePKCS12Payload) ExpectedPayloadType() string {
+	return PayloadTypeCertificatePKCS12
+}
+
+// Validate checks that PayloadContent is set and, if Password is set,
+// that it decrypts to an identity. Without a Password the user is
+// prompted for it when installing so the content is not checked.
+func (pl *CertificatePKCS12Payload) Validate() error {
+	if len(pl.PayloadContent) == 0 {
+		return errors.New("empty PKCS12 certificate payload content")
+	}
+	if pl.Password == "" {
+		return nil
+	}
+	if _, _, err := pl.Identity(); err != nil {
+		return fmt.Errorf("invalid PKCS12 certificate payload content: %w", err)
+	}
+	return nil
+}
+
+// CertificatePKCS12Payloads returns a slice of all payloads of that type
+func (p *Profile) CertificatePKCS12Payloads() (plds []*CertificatePKCS12Payload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*CertificatePKCS12Payload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// CertificateGenericPayload contains a certificate payload of a type
+// which is not otherwise modeled, such as a future certificate payload
+// type. It is only created when unmarshaling a payload whose PayloadType
+// is in the certificate family (see isCertificatePayloadType) and which
+// has data PayloadContent.
+type CertificateGenericPayload struct {
+	Payload
+	PayloadCertificateFileName string `plist:",omitempty"`
+	PayloadContent             []byte
+	Password                   string `plist:",omitempty"`
+}
+
+// CertificateGenericPayloads returns a slice of all payloads of that type
+func (p *Profile) CertificateGenericPayloads() (plds []*CertificateGenericPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*CertificateGenericPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// isCertificatePayloadType reports whether PayloadType t is in the
+// certificate payload family: one of the modeled certificate types or an
+// unmodeled "com.apple.security." type.
+func isCertificatePayloadType(t string) bool {
+	switch t {
+	case PayloadTypeCertificatePKCS1, PayloadTypeCertificateRoot,
+		PayloadTypeCertificatePEM, PayloadTypeCertificatePKCS12:
+		return true
+	}
+	if _, ok := newPayloadForType(t).(*Payload); !ok {
+		return false
+	}
+	return strings.HasPrefix(t, "com.apple.security.")
+}
+
+// CertificateRootPayload represents the "com.apple.security.root" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/certificateroot
+type CertificateRootPayload struct {
+	Payload
+	PayloadCertificateFileName string `plist:",omitempty"`
+	PayloadContent             []byte
+}
+
+// NewCertificateRootPayload creates a new payload with identifier i
+func NewCertificateRootPayload(i string) *CertificateRootPayload {
+	return &CertificateRootPayload{
+		Payload: *NewPayload(PayloadTypeCertificateRoot, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeCertificateRoot.
+func (pl *CertificateRootPayload) ExpectedPayloadType() string {
+	return PayloadTypeCertificateRoot
+}
+
+// Validate checks that PayloadContent contains a DER encoded certificate.
+func (pl *CertificateRootPayload) Validate() error {
+	if _, err := pl.Certificate(); err != nil {
+		return fmt.Errorf("invalid root certificate payload content: %w", err)
+	}
+	return nil
+}
+
+// CertificateRootPayloads returns a slice of all payloads of that type
+func (p *Profile) CertificateRootPayloads() (plds []*CertificateRootPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*CertificateRootPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// CertificatePEMPayload represents the "com.apple.security.pem" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/certificatepem
+type CertificatePEMPayload struct {
+	Payload
+	PayloadCertificateFileName string `plist:",omitempty"`
+	PayloadContent             []byte
+}
+
+// NewCertificatePEMPayload creates a new payload with identifier i
+func NewCertificatePEMPayload(i string) *CertificatePEMPayload {
+	return &CertificatePEMPayload{
+		Payload: *NewPayload(PayloadTypeCertificatePEM, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeCertificatePEM.
+func (pl *CertificatePEMPayload) ExpectedPayloadType() string {
+	return PayloadTypeCertificatePEM
+}
+
+// Validate checks that PayloadContent contains a PEM encoded certificate.
+func (pl *CertificatePEMPayload) Validate() error {
+	if _, err := pl.Certificate(); err != nil {
+		return fmt.Errorf("invalid PEM certificate payload content: %w", err)
+	}
+	return nil
+}
+
+// CertificatePEMPayloads returns a slice of all payloads of that type
+func (p *Profile) CertificatePEMPayloads() (plds []*CertificatePEMPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*CertificatePEMPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
 // SCEPPayloadContent represents the PayloadContent of the SCEPPayload
 // See https://developer.apple.com/documentation/devicemanagement/scep/payloadcontent
+//
+// Name is the CA instance name sent to the SCEP server as the message
+// parameter of its requests, which some servers use to select a CA. It
+// is never shown to users; the name shown for the payload is the
+// PayloadDisplayName of the SCEPPayload.
 type SCEPPayloadContent struct {
 	URL                string
 	Name               string          `plist:",omitempty"`
@@ -149,6 +524,143 @@ type SCEPPayloadContent struct {
 	SubjectAltName     *SubjectAltName `plist:",omitempty"`
 }
 
+// Apple's defaults for the SCEP Retries and RetryDelay keys, used by
+// devices when the keys are omitted.
+const (
+	DefaultSCEPRetries    = 3
+	DefaultSCEPRetryDelay = 10 * time.Second
+)
+
+// RetryPolicy is how a SCEP client retries the server when it responds
+// to a certificate request with PENDING: up to Retries more times,
+// waiting Delay between attempts.
+type RetryPolicy struct {
+	Retries int
+	Delay   time.Duration
+}
+
+// RetryPolicy returns the effective retry policy of the SCEP payload,
+// applying DefaultSCEPRetries and DefaultSCEPRetryDelay for omitted keys.
+// As the keys are omitted when zero a zero Retries or RetryDelay also
+// results in the default.
+func (c *SCEPPayloadContent) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Retries: c.EffectiveRetries(),
+		Delay:   c.EffectiveRetryDelay(),
+	}
+}
+
+// EffectiveRetries returns Retries or DefaultSCEPRetries if it is omitted.
+func (c *SCEPPayloadContent) EffectiveRetries() int {
+	if c.Retries == 0 {
+		return DefaultSCEPRetries
+	}
+	return c.Retries
+}
+
+// EffectiveRetryDelay returns RetryDelay as a duration or
+// DefaultSCEPRetryDelay if it is omitted.
+func (c *SCEPPayloadContent) EffectiveRetryDelay() time.Duration {
+	if c.RetryDelay == 0 {
+		return DefaultSCEPRetryDelay
+	}
+	return time.Duration(c.RetryDelay) * time.Second
+}
+
+// SetRetryPolicy sets the number of times the device retries the SCEP
+// server on a PENDING response and the delay in seconds between retries.
+// Zero values are omitted and so result in Apple's defaults (see
+// RetryPolicy).
+func (c *SCEPPayloadContent) SetRetryPolicy(retries, delaySeconds int) error {
+	if retries < 0 {
+		return fmt.Errorf("invalid SCEP retries: %d", retries)
+	}
+	if delaySeconds < 0 {
+		return fmt.Errorf("invalid SCEP retry delay: %d", delaySeconds)
+	}
+	c.Retries = retries
+	c.RetryDelay = delaySeconds
+	return nil
+}
+
+// AllowAllApps sets whether all apps have access to the private key.
+// It defaults to false, only giving access to the apps of the payload's
+// consumers (such as Wi-Fi or VPN).
+func (c *SCEPPayloadContent) AllowAllApps(allow bool) {
+	c.AllowAllAppsAccess = allow
+}
+
+// SetKeyExtractable sets whether the private key can be exported from
+// the keychain. It defaults to true.
+func (c *SCEPPayloadContent) SetKeyExtractable(extractable bool) {
+	c.KeyIsExtractable = &extractable
+}
+
+// Validate checks the SCEP payload content for invalid values, such as
+// a negative Retries or RetryDelay.
+func (c *SCEPPayloadContent) Validate() error {
+	if c.Retries < 0 {
+		return fmt.Errorf("invalid SCEP retries: %d", c.Retries)
+	}
+	if c.RetryDelay < 0 {
+		return fmt.Errorf("invalid SCEP retry delay: %d", c.RetryDelay)
+	}
+	switch c.KeySize {
+	case 0, 1024, 2048, 4096: // zero means device default (1024)
+	default:
+		return fmt.Errorf("invalid SCEP key size: %d", c.KeySize)
+	}
+	if err := validateSubjectOIDs(c.Subject); err != nil {
+		return err
+	}
+	return c.SubjectAltName.Validate()
+}
+
+// caFingerprintHash checks that h is a supported CA fingerprint hash,
+// defaulting to SHA-256 when h is zero.
+func caFingerprintHash(h crypto.Hash) (crypto.Hash, error) {
+	switch h {
+	case 0:
+		return crypto.SHA256, nil
+	case crypto.SHA1, crypto.SHA256:
+		return h, nil
+	default:
+		return 0, fmt.Errorf("unsupported CA fingerprint hash: %v", h)
+	}
+}
+
+// SetCAFingerprintFromCert sets CAFingerprint to the digest of cert using
+// hash h. If h is zero SHA-256 is used; SHA-1 may be used for legacy CAs.
+func (c *SCEPPayloadContent) SetCAFingerprintFromCert(cert *x509.Certificate, h crypto.Hash) error {
+	h, err := caFingerprintHash(h)
+	if err != nil {
+		return err
+	}
+	d := h.New()
+	d.Write(cert.Raw)
+	c.CAFingerprint = d.Sum(nil)
+	return nil
+}
+
+// VerifyCAFingerprint checks that CAFingerprint matches the digest of cert
+// using hash h. If h is zero the hash is selected by the fingerprint
+// length: SHA-1 for 20 bytes and SHA-256 otherwise.
+func (c *SCEPPayloadContent) VerifyCAFingerprint(cert *x509.Certificate, h crypto.Hash) error {
+	if h == 0 && len(c.CAFingerprint) == crypto.SHA1.Size() {
+		h = crypto.SHA1
+	}
+	h, err := caFingerprintHash(h)
+	if err != nil {
+		return err
+	}
+	d := h.New()
+	d.Write(cert.Raw)
+	if !bytes.Equal(d.Sum(nil), c.CAFingerprint) {
+		return errors.New("CA fingerprint does not match certificate")
+	}
+	return nil
+}
+
 // SCEPPayload represents the "com.apple.security.scep" PayloadType.
 // See https://developer.apple.com/documentation/devicemanagement/scep
 type SCEPPayload struct {
@@ -159,10 +671,15 @@ type SCEPPayload struct {
 // NewSCEPPayload creates a new payload with identifier i
 func NewSCEPPayload(i string) *SCEPPayload {
 	return &SCEPPayload{
-		Payload: *NewPayload("com.apple.security.scep", i),
+		Payload: *NewPayload(PayloadTypeSCEP, i),
 	}
 }
 
+// ExpectedPayloadType returns PayloadTypeSCEP.
+func (pl *SCEPPayload) ExpectedPayloadType() string {
+	return PayloadTypeSCEP
+}
+
 // SCEPPayloads returns a slice of all payloads of that type
 func (p *Profile) SCEPPayloads() (plds []*SCEPPayload) {
 	for _, pc := range p.PayloadContent {
@@ -173,6 +690,68 @@ func (p *Profile) SCEPPayloads() (plds []*SCEPPayload) {
 	return
 }
 
+// SCEPPayload returns the first payload of that type or nil
+func (p *Profile) SCEPPayload() *SCEPPayload {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*SCEPPayload); ok {
+			return pld
+		}
+	}
+	return nil
+}
+
+// Validate checks the SCEP payload content for invalid values.
+func (pl *SCEPPayload) Validate() error {
+	return pl.PayloadContent.Validate()
+}
+
+// SetCAName sets the CA instance name (PayloadContent.Name) sent to the
+// SCEP server. It is unrelated to the PayloadDisplayName shown to users.
+func (pl *SCEPPayload) SetCAName(name string) {
+	pl.PayloadContent.Name = name
+}
+
+// CAName returns the CA instance name (PayloadContent.Name).
+func (pl *SCEPPayload) CAName() string {
+	return pl.PayloadContent.Name
+}
+
+// RequireName returns an error if Name is empty. Validate does not
+// require a Name as most servers do not need one; call RequireName
+// additionally for servers which host several CAs and need the instance
+// name to select one.
+func (c *SCEPPayloadContent) RequireName() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("SCEP server requires a CA instance Name")
+	}
+	return nil
+}
+
+// SetChallenge sets the SCEP challenge.
+func (pl *SCEPPayload) SetChallenge(c string) {
+	pl.PayloadContent.Challenge = c
+}
+
+// Challenge returns the SCEP challenge.
+func (pl *SCEPPayload) Challenge() string {
+	return pl.PayloadContent.Challenge
+}
+
+// SetSCEPChallenge sets challenge c on all SCEP payloads of the profile.
+// Use it to inject a one-time challenge per device, for example into a
+// profile rendered from a Template:
+//
+//	p, err := tmpl.Render(vars)
+//	if err != nil {
+//		return err
+//	}
+//	p.SetSCEPChallenge(challenge)
+func (p *Profile) SetSCEPChallenge(c string) {
+	for _, pld := range p.SCEPPayloads() {
+		pld.SetChallenge(c)
+	}
+}
+
 // SubjectAltName contains the Subject Alternative Name details.
 // See https://developer.apple.com/documentation/devicemanagement/acmecertificate/subjectaltname
 //
@@ -202,67 +781,293 @@ func (p *Profile) SCEPPayloads() (plds []*SCEPPayload) {
 // <string>bob@example.com</string>
 // </array>
 // </dict>
+//
+// Entries for other name types are kept in OtherNames, keyed by their
+// dotted OID (e.g. "1.3.6.1.4.1.311.20.2.3" for a UPN), and are encoded
+// in the same dictionary as the named entries.
 type SubjectAltName struct {
-	DNSNames    multiString `plist:"dNSName,omitempty"`
-	NTPrincipal string      `plist:"ntPrincipalName,omitempty"`
-	RFC822Names multiString `plist:"rfc822Name,omitempty"`
-	URIs        multiString `plist:"uniformResourceIdentifier,omitempty"`
+	DNSNames    MultiString            `plist:"dNSName,omitempty"`
+	NTPrincipal string                 `plist:"ntPrincipalName,omitempty"`
+	RFC822Names MultiString            `plist:"rfc822Name,omitempty"`
+	URIs        MultiString            `plist:"uniformResourceIdentifier,omitempty"`
+	OtherNames  map[string]MultiString `plist:"-"`
 }
 
-type multiString []string
+// subjectAltName has the named fields of SubjectAltName without its
+// custom (un)marshalling.
+type subjectAltName SubjectAltName
 
-// UnmarshalPlist unmarshals the contents of a [multiString], which can
-// be either a single value or an array of strings.
-func (m *multiString) UnmarshalPlist(f func(interface{}) error) error {
-	var trySingle string
-	err := f(&trySingle)
-	if err == nil {
-		*m = []string{trySingle}
-		return nil
-	}
+// subjectAltNameKeys are the keys of the named SubjectAltName fields.
+var subjectAltNameKeys = map[string]bool{
+	"dNSName":                   true,
+	"ntPrincipalName":           true,
+	"rfc822Name":                true,
+	"uniformResourceIdentifier": true,
+}
 
-	var tryMulti []string
-	err = f(&tryMulti)
-	if err == nil {
-		*m = tryMulti
-		return nil
+// MarshalPlist marshals s including its OtherNames entries.
+func (s *SubjectAltName) MarshalPlist() (interface{}, error) {
+	var extra map[string]interface{}
+	for k, v := range s.OtherNames {
+		if len(v) == 0 {
+			continue
+		}
+		mv, err := v.MarshalPlist()
+		if err != nil {
+			return nil, err
+		}
+		if extra == nil {
+			extra = make(map[string]interface{})
+		}
+		extra[k] = mv
 	}
+	return marshalWithExtra((*subjectAltName)(s), extra)
+}
 
-	var umterr plist.UnmarshalTypeError
-	if errors.As(err, &umterr) {
-		umterr.Type = reflect.TypeOf(*m) // override type to cfgprofiles.multiString
-		return umterr
+// UnmarshalPlist unmarshals s, placing unknown keys into OtherNames.
+func (s *SubjectAltName) UnmarshalPlist(f func(interface{}) error) error {
+	var named subjectAltName
+	if err := f(&named); err != nil {
+		return err
+	}
+	var all map[string]MultiString
+	if err := f(&all); err != nil {
+		return err
+	}
+	for k, v := range all {
+		if subjectAltNameKeys[k] {
+			continue
+		}
+		if named.OtherNames == nil {
+			named.OtherNames = make(map[string]MultiString)
+		}
+		named.OtherNames[k] = v
 	}
+	*s = SubjectAltName(named)
+	return nil
+}
 
-	// fallback error; this is the most information we can provide
-	return fmt.Errorf("cannot unmarshal value into %T: %w", *m, err)
+// IsEmpty reports whether s has no Subject Alternative Name entries.
+func (s *SubjectAltName) IsEmpty() bool {
+	return s == nil || (len(s.DNSNames) == 0 &&
+		s.NTPrincipal == "" &&
+		len(s.RFC822Names) == 0 &&
+		len(s.URIs) == 0 &&
+		len(s.OtherNames) == 0)
 }
 
-// MarshalPlist marshals the contents of a [multiString], which can
-// be either a single value or slice of strings.
-func (m *multiString) MarshalPlist() (interface{}, error) {
-	switch n := *m; len(n) {
-	case 0:
-		return nil, fmt.Errorf("cannot marshal empty %T", n)
-	case 1:
-		return n[0], nil
-	default:
-		return append([]string{}, n...), nil
+// Merge adds the entries of other to s, skipping duplicates.
+// As only a single NT principal name is supported the NT principal name
+// of other is only used if s does not have one.
+func (s *SubjectAltName) Merge(other *SubjectAltName) {
+	if other == nil {
+		return
+	}
+	s.DNSNames = s.DNSNames.merge(other.DNSNames)
+	if s.NTPrincipal == "" {
+		s.NTPrincipal = other.NTPrincipal
+	}
+	s.RFC822Names = s.RFC822Names.merge(other.RFC822Names)
+	s.URIs = s.URIs.merge(other.URIs)
+	for k, v := range other.OtherNames {
+		if s.OtherNames == nil {
+			s.OtherNames = make(map[string]MultiString)
+		}
+		s.OtherNames[k] = s.OtherNames[k].merge(v)
 	}
 }
 
-// ACMECertificatePayload represents the "com.apple.security.acme" PayloadType.
-// See https://developer.apple.com/documentation/devicemanagement/acmecertificate
-type ACMECertificatePayload struct {
-	Payload
-	AllowAllAppsAccess bool            `plist:",omitempty"`
-	Attest             bool            `plist:",omitempty"`
-	ClientIdentifier   string          `plist:",omitempty"`
-	DirectoryURL       string          `plist:",omitempty"`
-	ExtendedKeyUsage   []string        `plist:",omitempty"`
-	HardwareBound      bool            `plist:",omitempty"`
-	KeySize            int             `plist:",omitempty"`
-	KeyIsExtractable   *bool           `plist:",omitempty"` // default true
+// Validate checks that DNS names are valid host names, RFC 822 names
+// are valid email addresses, URIs are absolute and other names are keyed
+// by valid OIDs. All invalid entries
+// are reported; several result in Errors.
+func (s *SubjectAltName) Validate() error {
+	if s == nil {
+		return nil
+	}
+	var errs Errors
+	for _, n := range s.DNSNames {
+		if !isHostname(n) {
+			errs = append(errs, fmt.Errorf("invalid SubjectAltName DNS name: %q", n))
+		}
+	}
+	for _, n := range s.RFC822Names {
+		if a, err := mail.ParseAddress(n); err != nil || a.Address != n {
+			errs = append(errs, fmt.Errorf("invalid SubjectAltName RFC 822 name: %q", n))
+		}
+	}
+	for _, n := range s.URIs {
+		if u, err := url.Parse(n); err != nil || !u.IsAbs() {
+			errs = append(errs, fmt.Errorf("invalid SubjectAltName URI: %q", n))
+		}
+	}
+	oids := make([]string, 0, len(s.OtherNames))
+	for k := range s.OtherNames {
+		oids = append(oids, k)
+	}
+	sort.Strings(oids)
+	for _, k := range oids {
+		if err := ValidateOID(k); err != nil {
+			errs = append(errs, fmt.Errorf("invalid SubjectAltName other name: %w", err))
+		}
+	}
+	return errs.errOrNil()
+}
+
+// isHostname reports whether h is a valid host name. A leading "*."
+// wildcard label is allowed.
+func isHostname(h string) bool {
+	h = strings.TrimPrefix(h, "*.")
+	if h == "" || len(h) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(h, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MultiString is a slice of strings which is encoded in a property list
+// as a single string when it has one value and as an array of strings
+// otherwise. It unmarshals from either form. Use it for keys which accept
+// a single string or an array of strings.
+type MultiString []string
+
+// merge returns m with the strings of other appended, skipping duplicates.
+func (m MultiString) merge(other MultiString) MultiString {
+	seen := make(map[string]bool, len(m))
+	for _, v := range m {
+		seen[v] = true
+	}
+	for _, v := range other {
+		if !seen[v] {
+			m = append(m, v)
+			seen[v] = true
+		}
+	}
+	return m
+}
+
+// UnmarshalPlist unmarshals the contents of a [MultiString], which can
+// be either a single value or an array of strings.
+func (m *MultiString) UnmarshalPlist(f func(interface{}) error) error {
+	s, err := unmarshalStrings(f, reflect.TypeOf(*m))
+	if err != nil {
+		return err
+	}
+	*m = s
+	return nil
+}
+
+// unmarshalStrings unmarshals either a single string or an array of
+// strings. Type errors are reported against type t.
+func unmarshalStrings(f func(interface{}) error, t reflect.Type) ([]string, error) {
+	var trySingle string
+	singleErr := f(&trySingle)
+	if singleErr == nil {
+		return []string{trySingle}, nil
+	}
+
+	var tryMulti []string
+	err := f(&tryMulti)
+	if err == nil {
+		return tryMulti, nil
+	}
+
+	// prefer a type error from either attempt, e.g. for dict values
+	var umterr plist.UnmarshalTypeError
+	if errors.As(err, &umterr) || errors.As(singleErr, &umterr) {
+		umterr.Type = t // override type to e.g. cfgprofiles.MultiString
+		return nil, umterr
+	}
+
+	// fallback error; this is the most information we can provide
+	return nil, fmt.Errorf("cannot unmarshal value into %s: %w", t, err)
+}
+
+// MarshalPlist marshals the contents of a [MultiString], which can
+// be either a single value or slice of strings.
+func (m *MultiString) MarshalPlist() (interface{}, error) {
+	switch n := *m; len(n) {
+	case 0:
+		return nil, fmt.Errorf("cannot marshal empty %T", n)
+	case 1:
+		return n[0], nil
+	default:
+		return append([]string{}, n...), nil
+	}
+}
+
+// unmarshalPayloadVersion unmarshals a payload dictionary into v using
+// f. Some third-party tools write PayloadVersion as a string or real; if
+// the dictionary does not unmarshal as is, such a PayloadVersion is
+// converted to an integer and the dictionary unmarshaled again.
+func unmarshalPayloadVersion(f func(interface{}) error, v interface{}) error {
+	err := f(v)
+	if err == nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if f(&m) != nil {
+		return err
+	}
+	switch ver := m["PayloadVersion"].(type) {
+	case string:
+		n, serr := strconv.Atoi(strings.TrimSpace(ver))
+		if serr != nil {
+			return fmt.Errorf("cannot unmarshal PayloadVersion %q: %w", ver, serr)
+		}
+		m["PayloadVersion"] = n
+	case float64:
+		if ver != float64(int(ver)) {
+			return fmt.Errorf("cannot unmarshal PayloadVersion %v: not an integer", ver)
+		}
+		m["PayloadVersion"] = int(ver)
+	default:
+		return err
+	}
+	b, merr := plist.Marshal(m)
+	if merr != nil {
+		return err
+	}
+	return plist.Unmarshal(b, v)
+}
+
+// StringArray is a slice of strings which unmarshals from either a single
+// string or an array of strings but always marshals as an array. Use it
+// for keys defined as arrays which are sometimes found as a single string.
+type StringArray []string
+
+// UnmarshalPlist unmarshals the contents of a [StringArray], which can
+// be either a single value or an array of strings.
+func (a *StringArray) UnmarshalPlist(f func(interface{}) error) error {
+	s, err := unmarshalStrings(f, reflect.TypeOf(*a))
+	if err != nil {
+		return err
+	}
+	*a = s
+	return nil
+}
+
+// ACMECertificatePayload represents the "com.apple.security.acme" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/acmecertificate
+type ACMECertificatePayload struct {
+	Payload
+	AllowAllAppsAccess bool            `plist:",omitempty"`
+	Attest             bool            `plist:",omitempty"`
+	ClientIdentifier   string          `plist:",omitempty"`
+	DirectoryURL       string          `plist:",omitempty"`
+	ExtendedKeyUsage   []string        `plist:",omitempty"`
+	HardwareBound      bool            `plist:",omitempty"`
+	KeySize            int             `plist:",omitempty"`
+	KeyIsExtractable   *bool           `plist:",omitempty"` // default true
 	KeyType            string          `plist:",omitempty"` // Possible values: RSA, ECSECPrimeRandom
 	Subject            [][][]string    `plist:",omitempty"` // Example: [ [ ["C", "US"] ], [ ["O", "Apple Inc."] ], ..., [ [ "1.2.5.3", "bar" ] ] ]
 	UsageFlags         int             `plist:",omitempty"`
@@ -272,10 +1077,72 @@ type ACMECertificatePayload struct {
 // NewACMECertificatePayload creates a new payload with identifier i
 func NewACMECertificatePayload(i string) *ACMECertificatePayload {
 	return &ACMECertificatePayload{
-		Payload: *NewPayload("com.apple.security.acme", i),
+		Payload: *NewPayload(PayloadTypeACME, i),
 	}
 }
 
+// ExpectedPayloadType returns PayloadTypeACME.
+func (pl *ACMECertificatePayload) ExpectedPayloadType() string {
+	return PayloadTypeACME
+}
+
+// KeyTypeECSECPrimeRandom is the elliptic curve ACME and SCEP KeyType.
+const KeyTypeECSECPrimeRandom = "ECSECPrimeRandom"
+
+// EnableAttestation enables device attestation for the ACME certificate
+// using client identifier clientID. Attestation requires the key to be
+// hardware bound, which in turn requires a P-256 or P-384 key; if the
+// key is not already one of those it is set to P-384.
+func (pl *ACMECertificatePayload) EnableAttestation(clientID string) {
+	pl.Attest = true
+	pl.HardwareBound = true
+	pl.ClientIdentifier = clientID
+	if pl.KeyType != KeyTypeECSECPrimeRandom || (pl.KeySize != 256 && pl.KeySize != 384) {
+		pl.KeyType = KeyTypeECSECPrimeRandom
+		pl.KeySize = 384
+	}
+}
+
+// Validate checks the ACME payload for invalid combinations of keys.
+// Attestation requires a hardware bound key and a hardware bound key must
+// be a non-extractable ECSECPrimeRandom key of 256 or 384 bits. Devices
+// fail these configurations without reporting an error to the server.
+// The DirectoryURL, if set, must be an HTTPS URL.
+func (pl *ACMECertificatePayload) Validate() error {
+	if pl.DirectoryURL != "" {
+		u, err := url.Parse(pl.DirectoryURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("ACME DirectoryURL is not an HTTPS URL: %q", pl.DirectoryURL)
+		}
+	}
+	if pl.Attest && !pl.HardwareBound {
+		return errors.New("ACME attestation requires HardwareBound")
+	}
+	if pl.Attest && pl.ClientIdentifier == "" {
+		return errors.New("ACME attestation requires ClientIdentifier")
+	}
+	if pl.HardwareBound {
+		if pl.KeyType != KeyTypeECSECPrimeRandom {
+			return fmt.Errorf("ACME hardware bound key requires KeyType %s, have %q", KeyTypeECSECPrimeRandom, pl.KeyType)
+		}
+		if pl.KeySize != 256 && pl.KeySize != 384 {
+			return fmt.Errorf("ACME hardware bound key requires KeySize 256 or 384, have %d", pl.KeySize)
+		}
+	}
+	if pl.HardwareBound && pl.KeyIsExtractable != nil && *pl.KeyIsExtractable {
+		return errors.New("ACME hardware bound key cannot be extractable")
+	}
+	for _, eku := range pl.ExtendedKeyUsage {
+		if err := ValidateOID(eku); err != nil {
+			return fmt.Errorf("invalid ACME ExtendedKeyUsage: %w", err)
+		}
+	}
+	if err := validateSubjectOIDs(pl.Subject); err != nil {
+		return err
+	}
+	return pl.SubjectAltName.Validate()
+}
+
 // ACMECertificatePayloads returns a slice of all payloads of that type
 func (p *Profile) ACMECertificatePayloads() (plds []*ACMECertificatePayload) {
 	for _, pc := range p.PayloadContent {
@@ -286,17 +1153,54 @@ func (p *Profile) ACMECertificatePayloads() (plds []*ACMECertificatePayload) {
 	return
 }
 
+// ACMEPayload returns the first payload of that type or nil
+func (p *Profile) ACMEPayload() *ACMECertificatePayload {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*ACMECertificatePayload); ok {
+			return pld
+		}
+	}
+	return nil
+}
+
+// ConvertSCEPToACME creates a new ACME payload from SCEP payload s using
+// the ACME directory URL directoryURL. The common payload keys and the
+// certificate request keys common to both are copied. The identifier is
+// copied but a new PayloadUUID is generated. Attestation is left off.
+// The Subject and SubjectAltName are deep copied, keeping the
+// single-value or array form of each SubjectAltName entry.
+//
+// The SCEP URL, Name, Challenge, Retries, RetryDelay and CAFingerprint
+// keys have no ACME equivalent and are not copied.
+func ConvertSCEPToACME(s *SCEPPayload, directoryURL string) *ACMECertificatePayload {
+	pl := NewACMECertificatePayload(s.PayloadIdentifier)
+	pl.PayloadDescription = s.PayloadDescription
+	pl.PayloadDisplayName = s.PayloadDisplayName
+	pl.PayloadOrganization = s.PayloadOrganization
+	pl.DirectoryURL = directoryURL
+	pl.KeyType = s.PayloadContent.KeyType
+	pl.KeySize = s.PayloadContent.KeySize
+	pl.UsageFlags = s.PayloadContent.KeyUsage
+	pl.AllowAllAppsAccess = s.PayloadContent.AllowAllAppsAccess
+	// deep copy so the payloads do not share slices, maps or pointers
+	pl.Subject = deepCopy(reflect.ValueOf(s.PayloadContent.Subject)).Interface().([][][]string)
+	pl.KeyIsExtractable = deepCopy(reflect.ValueOf(s.PayloadContent.KeyIsExtractable)).Interface().(*bool)
+	pl.SubjectAltName = deepCopy(reflect.ValueOf(s.PayloadContent.SubjectAltName)).Interface().(*SubjectAltName)
+	return pl
+}
+
 // MDMPayload represents the "com.apple.mdm" PayloadType.
 // See https://developer.apple.com/documentation/devicemanagement/mdm
 type MDMPayload struct {
 	Payload
+	binding
 	IdentityCertificateUUID           string
 	Topic                             string
 	ServerURL                         string
-	ServerCapabilities                []string `plist:",omitempty"`
-	SignMessage                       bool     `plist:",omitempty"`
-	CheckInURL                        string   `plist:",omitempty"`
-	CheckOutWhenRemoved               bool     `plist:",omitempty"`
+	ServerCapabilities                StringArray `plist:",omitempty"`
+	SignMessage                       bool        `plist:",omitempty"`
+	CheckInURL                        string      `plist:",omitempty"`
+	CheckOutWhenRemoved               bool        `plist:",omitempty"`
 	AccessRights                      int
 	UseDevelopmentAPNS                bool     `plist:",omitempty"`
 	ServerURLPinningCertificateUUIDs  []string `plist:",omitempty"`
@@ -307,8 +1211,52 @@ type MDMPayload struct {
 // NewMDMPayload creates a new payload with identifier i
 func NewMDMPayload(i string) *MDMPayload {
 	return &MDMPayload{
-		Payload: *NewPayload("com.apple.mdm", i),
+		Payload: *NewPayload(PayloadTypeMDM, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeMDM.
+func (pl *MDMPayload) ExpectedPayloadType() string {
+	return PayloadTypeMDM
+}
+
+// EnableCheckOut sets the device to send a CheckOut message to check-in
+// URL u when the MDM payload is removed.
+func (pl *MDMPayload) EnableCheckOut(u string) {
+	pl.CheckInURL = u
+	pl.CheckOutWhenRemoved = true
+}
+
+// SetProductionAPNS sets the device to use the production APNs
+// environment. This is the default and is required for devices enrolled
+// with a production push certificate.
+func (pl *MDMPayload) SetProductionAPNS() {
+	pl.UseDevelopmentAPNS = false
+}
+
+// SetDevelopmentAPNS sets the device to use the development APNs
+// environment. Lint warns about profiles using it.
+func (pl *MDMPayload) SetDevelopmentAPNS() {
+	pl.UseDevelopmentAPNS = true
+}
+
+// Validate checks the MDM payload for an incomplete check-in
+// configuration and for empty or duplicate ServerCapabilities.
+func (pl *MDMPayload) Validate() error {
+	if pl.CheckOutWhenRemoved && pl.CheckInURL == "" {
+		return errors.New("CheckOutWhenRemoved requires CheckInURL")
+	}
+	seen := make(map[string]bool, len(pl.ServerCapabilities))
+	for _, c := range pl.ServerCapabilities {
+		if c == "" {
+			return errors.New("empty server capability")
+		}
+		if seen[c] {
+			return fmt.Errorf("duplicate server capability: %q", c)
+		}
+		seen[c] = true
 	}
+	return nil
 }
 
 // MDMPayloads returns a slice of all payloads of that type
@@ -320,3 +1268,517 @@ func (p *Profile) MDMPayloads() (plds []*MDMPayload) {
 	}
 	return
 }
+
+// MDMPayload returns the first payload of that type or nil
+func (p *Profile) MDMPayload() *MDMPayload {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*MDMPayload); ok {
+			return pld
+		}
+	}
+	return nil
+}
+
+// RestrictionsPayload represents the "com.apple.applicationaccess"
+// (Restrictions) PayloadType. Only the autonomous single app mode key is
+// modeled; the other restrictions are kept in ExtraFields so that they
+// survive a round-trip.
+// See https://developer.apple.com/documentation/devicemanagement/restrictions
+type RestrictionsPayload struct {
+	Payload
+	AllowedApplications []string               `plist:"autonomousSingleAppModePermittedAppIDs,omitempty"`
+	ExtraFields         map[string]interface{} `plist:"-"`
+}
+
+// AutonomousSingleAppModePayload is a RestrictionsPayload used for its
+// autonomous single app mode key.
+type AutonomousSingleAppModePayload = RestrictionsPayload
+
+// restrictionsPayload has the fields of RestrictionsPayload without its
+// custom (un)marshalling.
+type restrictionsPayload RestrictionsPayload
+
+// MarshalPlist marshals pl including its ExtraFields entries.
+func (pl *RestrictionsPayload) MarshalPlist() (interface{}, error) {
+	return marshalWithExtra((*restrictionsPayload)(pl), pl.ExtraFields)
+}
+
+// UnmarshalPlist unmarshals pl, placing unknown keys into ExtraFields.
+func (pl *RestrictionsPayload) UnmarshalPlist(f func(interface{}) error) error {
+	var named restrictionsPayload
+	extra, err := unmarshalExtra(f, &named)
+	if err != nil {
+		return err
+	}
+	named.ExtraFields = extra
+	*pl = RestrictionsPayload(named)
+	return nil
+}
+
+// NewRestrictionsPayload creates a new payload with identifier i
+func NewRestrictionsPayload(i string) *RestrictionsPayload {
+	return &RestrictionsPayload{
+		Payload: *NewPayload(PayloadTypeRestrictions, i),
+	}
+}
+
+// NewAutonomousSingleAppModePayload creates a new payload with identifier i
+// for autonomous single app mode. Its AllowedApplications is empty but
+// not nil so that Validate requires at least one app bundle ID.
+func NewAutonomousSingleAppModePayload(i string) *AutonomousSingleAppModePayload {
+	pl := NewRestrictionsPayload(i)
+	pl.AllowedApplications = []string{}
+	return pl
+}
+
+// ExpectedPayloadType returns PayloadTypeRestrictions.
+func (pl *RestrictionsPayload) ExpectedPayloadType() string {
+	return PayloadTypeRestrictions
+}
+
+// Validate checks that, if the autonomous single app mode key is present,
+// it contains at least one app bundle ID and no empty ones. Restrictions
+// payloads without the key are valid.
+func (pl *RestrictionsPayload) Validate() error {
+	if pl.AllowedApplications == nil {
+		return nil
+	}
+	if len(pl.AllowedApplications) < 1 {
+		return errors.New("autonomous single app mode requires at least one app bundle ID")
+	}
+	for _, id := range pl.AllowedApplications {
+		if id == "" {
+			return errors.New("empty autonomous single app mode bundle ID")
+		}
+	}
+	return nil
+}
+
+// RestrictionsPayloads returns a slice of all payloads of that type
+func (p *Profile) RestrictionsPayloads() (plds []*RestrictionsPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*RestrictionsPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// AutonomousSingleAppModePayloads returns a slice of all Restrictions
+// payloads which have the autonomous single app mode key.
+func (p *Profile) AutonomousSingleAppModePayloads() (plds []*AutonomousSingleAppModePayload) {
+	for _, pld := range p.RestrictionsPayloads() {
+		if pld.AllowedApplications != nil {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// UniversalAccessPayload represents the "com.apple.universalaccess" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/accessibility
+type UniversalAccessPayload struct {
+	Payload
+	CloseViewZoomFactor *float64 `plist:"closeViewZoomFactor,omitempty"`
+	Contrast            *float64 `plist:"contrast,omitempty"`
+	FlashScreen         bool     `plist:"flashScreen,omitempty"`
+	Grayscale           bool     `plist:"grayscale,omitempty"`
+	MouseDriver         bool     `plist:"mouseDriver,omitempty"`
+	SlowKey             bool     `plist:"slowKey,omitempty"`
+	StickyKey           bool     `plist:"stickyKey,omitempty"`
+	VoiceOverOnOffKey   bool     `plist:"voiceOverOnOffKey,omitempty"`
+}
+
+// NewUniversalAccessPayload creates a new payload with identifier i
+func NewUniversalAccessPayload(i string) *UniversalAccessPayload {
+	return &UniversalAccessPayload{
+		Payload: *NewPayload(PayloadTypeUniversalAccess, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeUniversalAccess.
+func (pl *UniversalAccessPayload) ExpectedPayloadType() string {
+	return PayloadTypeUniversalAccess
+}
+
+// UniversalAccessPayloads returns a slice of all payloads of that type
+func (p *Profile) UniversalAccessPayloads() (plds []*UniversalAccessPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*UniversalAccessPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// FinderPayload represents the "com.apple.finder" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/finder
+type FinderPayload struct {
+	Payload
+	ProhibitConnectToServer *bool `plist:"ProhibitConnectTo,omitempty"`
+	ProhibitEject           *bool `plist:",omitempty"`
+	ProhibitBurn            *bool `plist:",omitempty"`
+	ProhibitGoToFolder      *bool `plist:",omitempty"`
+	ShowHardDrivesOnDesktop *bool `plist:",omitempty"`
+}
+
+// NewFinderPayload creates a new payload with identifier i
+func NewFinderPayload(i string) *FinderPayload {
+	return &FinderPayload{
+		Payload: *NewPayload(PayloadTypeFinder, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeFinder.
+func (pl *FinderPayload) ExpectedPayloadType() string {
+	return PayloadTypeFinder
+}
+
+// FinderPayloads returns a slice of all payloads of that type
+func (p *Profile) FinderPayloads() (plds []*FinderPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*FinderPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// Known Setup Assistant pane names for SkipSetupItems.
+// See https://developer.apple.com/documentation/devicemanagement/skipkeys
+const (
+	SkipSetupItemAccessibility     = "Accessibility"
+	SkipSetupItemAppearance        = "Appearance"
+	SkipSetupItemAppleID           = "AppleID"
+	SkipSetupItemBiometric         = "Biometric"
+	SkipSetupItemDiagnostics       = "Diagnostics"
+	SkipSetupItemDisplayTone       = "DisplayTone"
+	SkipSetupItemFileVault         = "FileVault"
+	SkipSetupItemICloudDiagnostics = "iCloudDiagnostics"
+	SkipSetupItemICloudStorage     = "iCloudStorage"
+	SkipSetupItemLocation          = "Location"
+	SkipSetupItemPayment           = "Payment"
+	SkipSetupItemPrivacy           = "Privacy"
+	SkipSetupItemRestore           = "Restore"
+	SkipSetupItemScreenTime        = "ScreenTime"
+	SkipSetupItemSiri              = "Siri"
+	SkipSetupItemTOS               = "TOS"
+	SkipSetupItemUnlockWithWatch   = "UnlockWithWatch"
+)
+
+var knownSkipSetupItems = map[string]bool{
+	SkipSetupItemAccessibility:     true,
+	SkipSetupItemAppearance:        true,
+	SkipSetupItemAppleID:           true,
+	SkipSetupItemBiometric:         true,
+	SkipSetupItemDiagnostics:       true,
+	SkipSetupItemDisplayTone:       true,
+	SkipSetupItemFileVault:         true,
+	SkipSetupItemICloudDiagnostics: true,
+	SkipSetupItemICloudStorage:     true,
+	SkipSetupItemLocation:          true,
+	SkipSetupItemPayment:           true,
+	SkipSetupItemPrivacy:           true,
+	SkipSetupItemRestore:           true,
+	SkipSetupItemScreenTime:        true,
+	SkipSetupItemSiri:              true,
+	SkipSetupItemTOS:               true,
+	SkipSetupItemUnlockWithWatch:   true,
+}
+
+// SetupAssistantPayload represents the "com.apple.SetupAssistant.managed" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/setupassistant
+type SetupAssistantPayload struct {
+	Payload
+	SkipSetupItems []string `plist:",omitempty"`
+}
+
+// NewSetupAssistantPayload creates a new payload with identifier i
+func NewSetupAssistantPayload(i string) *SetupAssistantPayload {
+	return &SetupAssistantPayload{
+		Payload: *NewPayload(PayloadTypeSetupAssistant, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeSetupAssistant.
+func (pl *SetupAssistantPayload) ExpectedPayloadType() string {
+	return PayloadTypeSetupAssistant
+}
+
+// Validate checks that no skip item is empty.
+// Unknown item names are not an error as Apple regularly adds new panes;
+// see UnknownSkipSetupItems.
+func (pl *SetupAssistantPayload) Validate() error {
+	for _, item := range pl.SkipSetupItems {
+		if item == "" {
+			return errors.New("empty Setup Assistant skip item")
+		}
+	}
+	return nil
+}
+
+// UnknownSkipSetupItems returns the skip items not in the known set of pane names.
+func (pl *SetupAssistantPayload) UnknownSkipSetupItems() (items []string) {
+	for _, item := range pl.SkipSetupItems {
+		if !knownSkipSetupItems[item] {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+// SetupAssistantPayloads returns a slice of all payloads of that type
+func (p *Profile) SetupAssistantPayloads() (plds []*SetupAssistantPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*SetupAssistantPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// AppConfigPayload represents the "com.apple.appconfig" PayloadType for
+// managed app configuration. Configuration is a free-form dictionary
+// delivered to the app identified by the App bundle identifier.
+type AppConfigPayload struct {
+	Payload
+	App           string
+	Configuration map[string]interface{} `plist:",omitempty"`
+}
+
+// NewAppConfigPayload creates a new payload with identifier i
+func NewAppConfigPayload(i string) *AppConfigPayload {
+	return &AppConfigPayload{
+		Payload: *NewPayload(PayloadTypeAppConfig, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeAppConfig.
+func (pl *AppConfigPayload) ExpectedPayloadType() string {
+	return PayloadTypeAppConfig
+}
+
+// AppConfigPayloads returns a slice of all payloads of that type
+func (p *Profile) AppConfigPayloads() (plds []*AppConfigPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*AppConfigPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// CertificateTransparencyHash identifies a certificate or CA by the hash of
+// its subject public key info.
+// See https://developer.apple.com/documentation/devicemanagement/certificatetransparency/disabledforcertsitem
+type CertificateTransparencyHash struct {
+	HashAlgorithm            string `plist:"Algorithm"` // Possible values: sha256
+	SubjectPublicKeyInfoHash []byte `plist:"Hash"`
+}
+
+// CertificateTransparencyPayload represents the "com.apple.security.certificatetransparency" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/certificatetransparency
+type CertificateTransparencyPayload struct {
+	Payload
+	DisabledForCAs     []CertificateTransparencyHash `plist:",omitempty"`
+	DisabledForCerts   []CertificateTransparencyHash `plist:",omitempty"`
+	DisabledForDomains []string                      `plist:",omitempty"`
+}
+
+// NewCertificateTransparencyPayload creates a new payload with identifier i
+func NewCertificateTransparencyPayload(i string) *CertificateTransparencyPayload {
+	return &CertificateTransparencyPayload{
+		Payload: *NewPayload(PayloadTypeCertificateTransparency, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeCertificateTransparency.
+func (pl *CertificateTransparencyPayload) ExpectedPayloadType() string {
+	return PayloadTypeCertificateTransparency
+}
+
+// CertificateTransparencyPayloads returns a slice of all payloads of that type
+func (p *Profile) CertificateTransparencyPayloads() (plds []*CertificateTransparencyPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*CertificateTransparencyPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// CustomSettingsPayload represents the "com.apple.ManagedClient.preferences" PayloadType.
+// PayloadContent maps preference domains to their free-form settings.
+// See https://developer.apple.com/documentation/devicemanagement/applicationpreferences
+type CustomSettingsPayload struct {
+	Payload
+	PayloadContent map[string]map[string]interface{}
+}
+
+// NewCustomSettingsPayload creates a new payload with identifier i
+func NewCustomSettingsPayload(i string) *CustomSettingsPayload {
+	return &CustomSettingsPayload{
+		Payload:        *NewPayload(PayloadTypeCustomSettings, i),
+		PayloadContent: make(map[string]map[string]interface{}),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeCustomSettings.
+func (pl *CustomSettingsPayload) ExpectedPayloadType() string {
+	return PayloadTypeCustomSettings
+}
+
+// CustomSettingsPayloads returns a slice of all payloads of that type
+func (p *Profile) CustomSettingsPayloads() (plds []*CustomSettingsPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*CustomSettingsPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// MCXPreferenceSet is a set of managed preferences for a domain.
+// MCXDataTimestamp is used by Set-Once sets: the settings are applied
+// again when it changes.
+type MCXPreferenceSet struct {
+	MCXDataTimestamp      *time.Time             `plist:"mcx_data_timestamp,omitempty"`
+	MCXPreferenceSettings map[string]interface{} `plist:"mcx_preference_settings"`
+}
+
+// MCXDomainSettings contains the managed preference sets for a domain.
+// Forced sets are always enforced while Set-Once sets are only applied
+// initially and may then be changed by the user.
+type MCXDomainSettings struct {
+	Forced  []MCXPreferenceSet `plist:",omitempty"`
+	SetOnce []MCXPreferenceSet `plist:"Set-Once,omitempty"`
+}
+
+// ManagedPreferencesPayload represents the "com.apple.defaults" PayloadType.
+// PayloadContent maps preference domains to their managed (MCX) preference sets.
+type ManagedPreferencesPayload struct {
+	Payload
+	PayloadContent map[string]MCXDomainSettings
+}
+
+// NewManagedPreferencesPayload creates a new payload with identifier i
+func NewManagedPreferencesPayload(i string) *ManagedPreferencesPayload {
+	return &ManagedPreferencesPayload{
+		Payload:        *NewPayload(PayloadTypeManagedPreferences, i),
+		PayloadContent: make(map[string]MCXDomainSettings),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeManagedPreferences.
+func (pl *ManagedPreferencesPayload) ExpectedPayloadType() string {
+	return PayloadTypeManagedPreferences
+}
+
+// AddForced adds a Forced preference set with settings for domain. Forced
+// settings are always enforced.
+func (pl *ManagedPreferencesPayload) AddForced(domain string, settings map[string]interface{}) {
+	if pl.PayloadContent == nil {
+		pl.PayloadContent = make(map[string]MCXDomainSettings)
+	}
+	ds := pl.PayloadContent[domain]
+	ds.Forced = append(ds.Forced, MCXPreferenceSet{MCXPreferenceSettings: settings})
+	pl.PayloadContent[domain] = ds
+}
+
+// AddSetOnce adds a Set-Once preference set with settings for domain.
+// Set-Once settings are applied initially and may then be changed by the
+// user. The set's MCXDataTimestamp is set to ts, truncated to whole
+// seconds; use a later ts to have devices apply the settings again.
+func (pl *ManagedPreferencesPayload) AddSetOnce(domain string, settings map[string]interface{}, ts time.Time) {
+	if pl.PayloadContent == nil {
+		pl.PayloadContent = make(map[string]MCXDomainSettings)
+	}
+	ts = ts.UTC().Truncate(time.Second)
+	ds := pl.PayloadContent[domain]
+	ds.SetOnce = append(ds.SetOnce, MCXPreferenceSet{MCXDataTimestamp: &ts, MCXPreferenceSettings: settings})
+	pl.PayloadContent[domain] = ds
+}
+
+// ManagedPreferencesPayloads returns a slice of all payloads of that type
+func (p *Profile) ManagedPreferencesPayloads() (plds []*ManagedPreferencesPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*ManagedPreferencesPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// ProfileRemovalPasswordPayload represents the "com.apple.profileRemovalPassword" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/profileremovalpassword
+type ProfileRemovalPasswordPayload struct {
+	Payload
+	RemovalPassword string `plist:",omitempty"`
+}
+
+// NewProfileRemovalPasswordPayload creates a new payload with identifier i
+func NewProfileRemovalPasswordPayload(i string) *ProfileRemovalPasswordPayload {
+	return &ProfileRemovalPasswordPayload{
+		Payload: *NewPayload(PayloadTypeProfileRemovalPassword, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeProfileRemovalPassword.
+func (pl *ProfileRemovalPasswordPayload) ExpectedPayloadType() string {
+	return PayloadTypeProfileRemovalPassword
+}
+
+// ProfileRemovalPasswordPayloads returns a slice of all payloads of that type
+func (p *Profile) ProfileRemovalPasswordPayloads() (plds []*ProfileRemovalPasswordPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*ProfileRemovalPasswordPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// ApplicationAccessApp identifies an app in the allow list of the
+// ApplicationAccessPayload.
+type ApplicationAccessApp struct {
+	BundleID          string `plist:"bundleID"`
+	DisplayName       string `plist:"displayName,omitempty"`
+	Disabled          bool   `plist:"disabled,omitempty"`
+	AppID             []byte `plist:"appID,omitempty"`
+	DetachedSignature []byte `plist:"detachedSignature,omitempty"`
+}
+
+// ApplicationAccessPayload represents the "com.apple.applicationaccess.new" PayloadType.
+// It is the macOS per-app allow and deny list, commonly combined with the
+// base restrictions payload.
+// See https://developer.apple.com/documentation/devicemanagement/applicationaccessnew
+type ApplicationAccessPayload struct {
+	Payload
+	FamilyControlsEnabled bool                   `plist:"familyControlsEnabled"`
+	AllowedApps           []ApplicationAccessApp `plist:"whiteList,omitempty"`
+	AllowedPaths          []string               `plist:"pathWhiteList,omitempty"`
+	DeniedPaths           []string               `plist:"pathBlackList,omitempty"`
+}
+
+// NewApplicationAccessPayload creates a new payload with identifier i
+func NewApplicationAccessPayload(i string) *ApplicationAccessPayload {
+	return &ApplicationAccessPayload{
+		Payload:               *NewPayload(PayloadTypeApplicationAccess, i),
+		FamilyControlsEnabled: true,
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeApplicationAccess.
+func (pl *ApplicationAccessPayload) ExpectedPayloadType() string {
+	return PayloadTypeApplicationAccess
+}
+
+// ApplicationAccessPayloads returns a slice of all payloads of that type
+func (p *Profile) ApplicationAccessPayloads() (plds []*ApplicationAccessPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*ApplicationAccessPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
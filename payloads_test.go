@@ -1,15 +1,20 @@
 package cfgprofiles
 
 import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/micromdm/plist"
 )
 
-func Test_multiString_UnmarshalPlist_error(t *testing.T) {
+func TestMultiString_UnmarshalPlist_error(t *testing.T) {
 	plBytes, err := ioutil.ReadFile(filepath.Join("testdata", "multistring-error.mobileconfig"))
 	fatalIf(t, err)
 
@@ -19,7 +24,27 @@ func Test_multiString_UnmarshalPlist_error(t *testing.T) {
 		t.Error("expected an error")
 	}
 
-	expectedErrorMessage := "plist: cannot unmarshal 42 into Go value of type cfgprofiles.multiString"
+	expectedErrorMessage := "plist: cannot unmarshal 42 into Go value of type cfgprofiles.MultiString"
+	if err.Error() != expectedErrorMessage {
+		t.Errorf("have %q, want %q", err.Error(), expectedErrorMessage)
+	}
+}
+
+func TestMultiString_UnmarshalPlist_dictError(t *testing.T) {
+	plBytes, err := ioutil.ReadFile(filepath.Join("testdata", "multistring-dict-error.mobileconfig"))
+	fatalIf(t, err)
+
+	p := &Profile{}
+	err = plist.Unmarshal(plBytes, p)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var umterr plist.UnmarshalTypeError
+	if !errors.As(err, &umterr) {
+		t.Errorf("expected plist.UnmarshalTypeError, have %T", err)
+	}
+	expectedErrorMessage := "plist: cannot unmarshal dict into Go value of type cfgprofiles.MultiString"
 	if err.Error() != expectedErrorMessage {
 		t.Errorf("have %q, want %q", err.Error(), expectedErrorMessage)
 	}
@@ -41,27 +66,910 @@ func Test_multipleNTPrincipalNames_UnmarshalPlist_error(t *testing.T) {
 	}
 }
 
-func Test_multiString_MarshalPlist(t *testing.T) {
+func TestMultiString_MarshalPlist(t *testing.T) {
 	tests := []struct {
 		name    string
-		m       *multiString
+		m       *MultiString
 		want    interface{}
 		wantErr bool
 	}{
-		{"zero", &multiString{}, nil, true},
-		{"one", &multiString{"test.example.com"}, "test.example.com", false},
-		{"multiple", &multiString{"test1.example.com", "test2.example.com"}, []string{"test1.example.com", "test2.example.com"}, false},
+		{"zero", &MultiString{}, nil, true},
+		{"one", &MultiString{"test.example.com"}, "test.example.com", false},
+		{"multiple", &MultiString{"test1.example.com", "test2.example.com"}, []string{"test1.example.com", "test2.example.com"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := tt.m.MarshalPlist()
 			if (err != nil) != tt.wantErr {
-				t.Errorf("multiString.MarshalPlist() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("MultiString.MarshalPlist() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("multiString.MarshalPlist() = %v, want %v", got, tt.want)
+				t.Errorf("MultiString.MarshalPlist() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSCEPPayloadContent_RetryPolicy(t *testing.T) {
+	c := &SCEPPayloadContent{}
+	want := RetryPolicy{Retries: DefaultSCEPRetries, Delay: DefaultSCEPRetryDelay}
+	if have := c.RetryPolicy(); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	fatalIf(t, c.SetRetryPolicy(5, 30))
+	want = RetryPolicy{Retries: 5, Delay: 30 * time.Second}
+	if have := c.RetryPolicy(); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	c.Retries = -1
+	if err := c.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSCEPPayloadContent_SetRetryPolicy(t *testing.T) {
+	c := &SCEPPayloadContent{}
+	if err := c.SetRetryPolicy(-1, 10); err == nil {
+		t.Error("expected an error")
+	}
+	if err := c.SetRetryPolicy(3, -1); err == nil {
+		t.Error("expected an error")
+	}
+	fatalIf(t, c.SetRetryPolicy(5, 30))
+	if c.Retries != 5 || c.RetryDelay != 30 {
+		t.Errorf("have %d/%d, want %d/%d", c.Retries, c.RetryDelay, 5, 30)
+	}
+	fatalIf(t, c.Validate())
+
+	c.KeySize = 3000
+	if err := c.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestACMECertificatePayload_Validate(t *testing.T) {
+	pl := NewACMECertificatePayload("com.example.acme")
+	fatalIf(t, pl.Validate())
+
+	pl.Attest = true
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pl.EnableAttestation("2678F47F-7A0B-4E7E-BEBC-29C1DCAF28C6")
+	fatalIf(t, pl.Validate())
+
+	extractable := true
+	pl.KeyIsExtractable = &extractable
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestACMECertificatePayload_ValidateHardwareBound(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		keyType string
+		keySize int
+		ok      bool
+	}{
+		{"P-256", KeyTypeECSECPrimeRandom, 256, true},
+		{"P-384", KeyTypeECSECPrimeRandom, 384, true},
+		{"P-521", KeyTypeECSECPrimeRandom, 521, false},
+		{"RSA", "RSA", 2048, false},
+		{"no key size", KeyTypeECSECPrimeRandom, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := NewACMECertificatePayload("com.example.acme")
+			pl.HardwareBound = true
+			pl.KeyType = tt.keyType
+			pl.KeySize = tt.keySize
+			if err := pl.Validate(); (err == nil) != tt.ok {
+				t.Errorf("have error %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestACMECertificatePayload_EnableAttestationKey(t *testing.T) {
+	pl := NewACMECertificatePayload("com.example.acme")
+	pl.KeyType = "RSA"
+	pl.KeySize = 2048
+	pl.EnableAttestation("2678F47F-7A0B-4E7E-BEBC-29C1DCAF28C6")
+	if pl.KeyType != KeyTypeECSECPrimeRandom || pl.KeySize != 384 {
+		t.Errorf("have %s %d, want %s 384", pl.KeyType, pl.KeySize, KeyTypeECSECPrimeRandom)
+	}
+
+	pl.KeySize = 256
+	pl.EnableAttestation("2678F47F-7A0B-4E7E-BEBC-29C1DCAF28C6")
+	if pl.KeySize != 256 {
+		t.Errorf("have %d, want 256", pl.KeySize)
+	}
+}
+
+func TestACMECertificatePayload_ValidateDirectoryURL(t *testing.T) {
+	for _, u := range []string{
+		"http://acme.example.com/directory",
+		"acme.example.com/directory",
+		"https:///directory",
+		"://bad",
+	} {
+		pl := NewACMECertificatePayload("com.example.acme")
+		pl.DirectoryURL = u
+		if err := pl.Validate(); err == nil {
+			t.Errorf("expected an error for %q", u)
+		}
+	}
+	pl := NewACMECertificatePayload("com.example.acme")
+	pl.DirectoryURL = "https://acme.example.com/directory"
+	fatalIf(t, pl.Validate())
+}
+
+func TestACMECertificatePayload_EmptySubject(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		subject [][][]string
+		eku     []string
+	}{
+		{"nil", nil, nil},
+		{"empty", [][][]string{}, []string{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := NewACMECertificatePayload("com.example.acme")
+			pl.Subject = tt.subject
+			pl.ExtendedKeyUsage = tt.eku
+			b, err := plist.Marshal(pl)
+			fatalIf(t, err)
+			var m map[string]interface{}
+			fatalIf(t, plist.Unmarshal(b, &m))
+			for _, k := range []string{"Subject", "ExtendedKeyUsage"} {
+				if _, ok := m[k]; ok {
+					t.Errorf("expected %s key to be omitted", k)
+				}
+			}
+		})
+	}
+}
+
+func TestAutonomousSingleAppModePayload(t *testing.T) {
+	pl := NewAutonomousSingleAppModePayload("com.example.asam")
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+	pl.AllowedApplications = []string{"com.example.testapp"}
+	fatalIf(t, pl.Validate())
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.AutonomousSingleAppModePayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestRestrictionsPayload(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "restrictions.mobileconfig"))
+	fatalIf(t, err)
+	p := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p))
+	pls := p.RestrictionsPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	pl := pls[0]
+	fatalIf(t, pl.Validate())
+	fatalIf(t, p.Validate())
+	if len(p.AutonomousSingleAppModePayloads()) != 0 {
+		t.Error("expected no autonomous single app mode payloads")
+	}
+	want := map[string]interface{}{
+		"allowAirDrop":         false,
+		"allowCamera":          false,
+		"forceEncryptedBackup": true,
+		"ratingApps":           uint64(1000),
+	}
+	if !reflect.DeepEqual(pl.ExtraFields, want) {
+		t.Errorf("have %v, want %v", pl.ExtraFields, want)
+	}
+
+	// restrictions survive a round-trip
+	b2, err := plist.Marshal(p)
+	fatalIf(t, err)
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b2, p2))
+	if !reflect.DeepEqual(p2, p) {
+		t.Errorf("have %#+v, want %#+v", p2, p)
+	}
+}
+
+func TestUniversalAccessPayload(t *testing.T) {
+	pl := NewUniversalAccessPayload("com.example.universalaccess")
+	zoom := 1.5
+	pl.CloseViewZoomFactor = &zoom
+	pl.VoiceOverOnOffKey = true
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.UniversalAccessPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+	if pls[0].Contrast != nil {
+		t.Error("expected nil contrast")
+	}
+}
+
+func TestFinderPayload(t *testing.T) {
+	pl := NewFinderPayload("com.example.finder")
+	prohibit, show := true, false
+	pl.ProhibitConnectToServer = &prohibit
+	pl.ShowHardDrivesOnDesktop = &show
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	if !bytes.Contains(b, []byte("<key>ShowHardDrivesOnDesktop</key><false/>")) {
+		t.Error("expected explicit false ShowHardDrivesOnDesktop")
+	}
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.FinderPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestSetupAssistantPayload(t *testing.T) {
+	pl := NewSetupAssistantPayload("com.example.setupassistant")
+	pl.SkipSetupItems = []string{SkipSetupItemSiri, "NewPane", SkipSetupItemPrivacy}
+	fatalIf(t, pl.Validate())
+
+	if have, want := pl.UnknownSkipSetupItems(), []string{"NewPane"}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	pl.SkipSetupItems = append(pl.SkipSetupItems, "")
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestAppConfigPayload(t *testing.T) {
+	pl := NewAppConfigPayload("com.example.appconfig")
+	pl.App = "com.example.app"
+	pl.Configuration = map[string]interface{}{
+		"ServerURL": "https://example.com",
+		"Enabled":   true,
+		"Ports":     []interface{}{uint64(80), uint64(443)},
+		"Nested": map[string]interface{}{
+			"Level": uint64(2),
+			"Deeper": map[string]interface{}{
+				"Names": []interface{}{"a", "b"},
+			},
+		},
+	}
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.AppConfigPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestConvertSCEPToACME(t *testing.T) {
+	s := NewSCEPPayload("com.example.scep")
+	s.PayloadDisplayName = "Device Identity"
+	s.PayloadContent = SCEPPayloadContent{
+		URL:       "https://scep.example.com/scep",
+		Challenge: "secret",
+		Subject:   [][][]string{{{"CN", "device"}}},
+		KeyType:   "RSA",
+		KeySize:   2048,
+		KeyUsage:  5,
+		SubjectAltName: &SubjectAltName{
+			DNSNames: MultiString{"device.example.com"},
+		},
+	}
+
+	a := ConvertSCEPToACME(s, "https://acme.example.com/directory")
+
+	if a.PayloadUUID == s.PayloadUUID {
+		t.Error("expected a new PayloadUUID")
+	}
+	if a.PayloadIdentifier != s.PayloadIdentifier || a.PayloadDisplayName != s.PayloadDisplayName {
+		t.Error("expected common payload keys to be copied")
+	}
+	if a.DirectoryURL != "https://acme.example.com/directory" {
+		t.Errorf("have %q, want %q", a.DirectoryURL, "https://acme.example.com/directory")
+	}
+	if a.KeyType != "RSA" || a.KeySize != 2048 || a.UsageFlags != 5 {
+		t.Error("expected key properties to be copied")
+	}
+	if !reflect.DeepEqual(a.Subject, s.PayloadContent.Subject) {
+		t.Errorf("have %v, want %v", a.Subject, s.PayloadContent.Subject)
+	}
+	if !reflect.DeepEqual(a.SubjectAltName, s.PayloadContent.SubjectAltName) {
+		t.Errorf("have %v, want %v", a.SubjectAltName, s.PayloadContent.SubjectAltName)
+	}
+	if a.Attest || a.HardwareBound {
+		t.Error("expected attestation to be off")
+	}
+}
+
+func TestConvertSCEPToACMEDeepCopy(t *testing.T) {
+	s := NewSCEPPayload("com.example.scep")
+	s.PayloadContent.Subject = [][][]string{{{"CN", "device"}}}
+	s.PayloadContent.SubjectAltName = &SubjectAltName{
+		DNSNames:   MultiString{"device.example.com"},
+		URIs:       MultiString{"https://example.com/a", "https://example.com/b"},
+		OtherNames: map[string]MultiString{"otherName": {"value"}},
+	}
+	a := ConvertSCEPToACME(s, "https://acme.example.com/directory")
+
+	a.SubjectAltName.DNSNames[0] = "changed.example.com"
+	a.SubjectAltName.URIs[1] = "https://example.com/changed"
+	a.SubjectAltName.OtherNames["otherName"][0] = "changed"
+	a.Subject[0][0][1] = "changed"
+
+	san := s.PayloadContent.SubjectAltName
+	if have, want := san.DNSNames[0], "device.example.com"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := san.URIs[1], "https://example.com/b"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := san.OtherNames["otherName"][0], "value"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := s.PayloadContent.Subject[0][0][1], "device"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	// the single string form of MultiString is kept
+	b, err := plist.Marshal(a)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>dNSName</key><string>changed.example.com</string>")) {
+		t.Errorf("expected single dNSName string in %s", b)
+	}
+}
+
+func TestCertificatePayloadValidate(t *testing.T) {
+	cert := GetCertData(t)
+
+	pkcs1 := NewCertificatePKCS1Payload("com.example.pkcs1")
+	pkcs1.PayloadContent = cert.Raw
+	fatalIf(t, pkcs1.Validate())
+	pkcs1.PayloadContent = cert.Raw[:len(cert.Raw)/2]
+	if err := pkcs1.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	root := NewCertificateRootPayload("com.example.root")
+	root.PayloadContent = cert.Raw
+	fatalIf(t, root.Validate())
+	root.PayloadContent = nil
+	if err := root.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pemPld := NewCertificatePEMPayload("com.example.pem")
+	var err error
+	pemPld.PayloadContent, err = ioutil.ReadFile(filepath.Join("testdata", "entrust.pem"))
+	fatalIf(t, err)
+	fatalIf(t, pemPld.Validate())
+	pemPld.PayloadContent = cert.Raw
+	if err := pemPld.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestCertificateTransparencyPayload(t *testing.T) {
+	pl := NewCertificateTransparencyPayload("com.example.ct")
+	pl.DisabledForCerts = []CertificateTransparencyHash{
+		{HashAlgorithm: "sha256", SubjectPublicKeyInfoHash: []byte{0x01, 0x02, 0x03}},
+	}
+	pl.DisabledForDomains = []string{".example.com"}
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.CertificateTransparencyPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestSubjectAltName_Merge(t *testing.T) {
+	var nilSAN *SubjectAltName
+	if !nilSAN.IsEmpty() {
+		t.Error("expected nil SubjectAltName to be empty")
+	}
+	s := &SubjectAltName{}
+	if !s.IsEmpty() {
+		t.Error("expected zero SubjectAltName to be empty")
+	}
+
+	s.DNSNames = MultiString{"a.example.com"}
+	s.Merge(&SubjectAltName{
+		DNSNames:    MultiString{"a.example.com", "b.example.com"},
+		NTPrincipal: "user@example.com",
+		RFC822Names: MultiString{"alice@example.com"},
+	})
+	s.Merge(nil)
+
+	want := &SubjectAltName{
+		DNSNames:    MultiString{"a.example.com", "b.example.com"},
+		NTPrincipal: "user@example.com",
+		RFC822Names: MultiString{"alice@example.com"},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("have %#+v, want %#+v", s, want)
+	}
+	if s.IsEmpty() {
+		t.Error("expected SubjectAltName not to be empty")
+	}
+}
+
+func TestSubjectAltName_OtherNames(t *testing.T) {
+	const upnOID = "1.3.6.1.4.1.311.20.2.3"
+	s := &SubjectAltName{
+		DNSNames: MultiString{"a.example.com"},
+		OtherNames: map[string]MultiString{
+			upnOID:        {"user@example.com"},
+			"1.2.3.4.5.6": {"one", "two"},
+		},
+	}
+	b, err := plist.Marshal(s)
+	fatalIf(t, err)
+	b2, err := plist.Marshal(s)
+	fatalIf(t, err)
+	if !bytes.Equal(b, b2) {
+		t.Error("expected stable marshalling")
+	}
+
+	var m map[string]interface{}
+	fatalIf(t, plist.Unmarshal(b, &m))
+	if have, want := m[upnOID], "user@example.com"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	s2 := &SubjectAltName{}
+	fatalIf(t, plist.Unmarshal(b, s2))
+	if !reflect.DeepEqual(s, s2) {
+		t.Errorf("have %#+v, want %#+v", s2, s)
+	}
+
+	s.OtherNames["dNSName"] = MultiString{"b.example.com"}
+	if _, err := plist.Marshal(s); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestMDMPayloadSingleServerCapability(t *testing.T) {
+	plBytes, err := ioutil.ReadFile(filepath.Join("testdata", "mdm-single-capability.mobileconfig"))
+	fatalIf(t, err)
+
+	p := &Profile{}
+	fatalIf(t, plist.Unmarshal(plBytes, p))
+
+	pls := p.MDMPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	want := StringArray{"com.apple.mdm.per-user-connections"}
+	if !reflect.DeepEqual(pls[0].ServerCapabilities, want) {
+		t.Errorf("have %v, want %v", pls[0].ServerCapabilities, want)
+	}
+
+	// always marshals as an array
+	b, err := plist.Marshal(pls[0])
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>ServerCapabilities</key><array><string>com.apple.mdm.per-user-connections</string></array>")) {
+		t.Errorf("expected ServerCapabilities array, have %s", b)
+	}
+}
+
+func TestCustomSettingsPayload(t *testing.T) {
+	pl := NewCustomSettingsPayload("com.example.customsettings")
+	pl.PayloadContent["com.example.app"] = map[string]interface{}{
+		"Forced": []interface{}{
+			map[string]interface{}{
+				"mcx_preference_settings": map[string]interface{}{
+					"ServerURL": "https://example.com",
+					"Retries":   uint64(3),
+					"Enabled":   false,
+					"Blob":      []byte{0x00, 0x01},
+				},
+			},
+		},
+	}
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.CustomSettingsPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestManagedPreferencesPayload(t *testing.T) {
+	pl := NewManagedPreferencesPayload("com.example.defaults")
+	pl.PayloadContent["com.apple.screensaver"] = MCXDomainSettings{
+		Forced: []MCXPreferenceSet{
+			{MCXPreferenceSettings: map[string]interface{}{
+				"idleTime":       uint64(600),
+				"askForPassword": true,
+			}},
+		},
+	}
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	if !bytes.Contains(b, []byte("<key>Forced</key><array><dict><key>mcx_preference_settings</key>")) {
+		t.Errorf("expected Forced preference set, have %s", b)
+	}
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.ManagedPreferencesPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestManagedPreferencesPayloadSetOnce(t *testing.T) {
+	pl := NewManagedPreferencesPayload("com.example.defaults")
+	pl.AddForced("com.apple.screensaver", map[string]interface{}{"idleTime": uint64(600)})
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	pl.AddSetOnce("com.apple.dock", map[string]interface{}{"autohide": true}, ts)
+
+	ds := pl.PayloadContent["com.apple.dock"]
+	if len(ds.Forced) != 0 || len(ds.SetOnce) != 1 {
+		t.Fatalf("unexpected preference sets %#+v", ds)
+	}
+	if ts := ds.SetOnce[0].MCXDataTimestamp; ts == nil || !ts.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected Set-Once timestamp %v", ts)
+	}
+	if len(pl.PayloadContent["com.apple.screensaver"].Forced) != 1 {
+		t.Error("expected Forced preference set")
+	}
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>Set-Once</key><array><dict><key>mcx_data_timestamp</key><date>2024-05-01T12:00:00Z</date>")) {
+		t.Errorf("expected Set-Once preference set, have %s", b)
+	}
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.ManagedPreferencesPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestSCEPPayloadContent_CAFingerprint(t *testing.T) {
+	cert := GetCertData(t)
+	for _, tt := range []struct {
+		name string
+		hash crypto.Hash
+		size int
+	}{
+		{"default", 0, 32},
+		{"sha1", crypto.SHA1, 20},
+		{"sha256", crypto.SHA256, 32},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &SCEPPayloadContent{}
+			fatalIf(t, c.SetCAFingerprintFromCert(cert, tt.hash))
+			if len(c.CAFingerprint) != tt.size {
+				t.Errorf("have %d byte fingerprint, want %d", len(c.CAFingerprint), tt.size)
+			}
+			fatalIf(t, c.VerifyCAFingerprint(cert, tt.hash))
+			fatalIf(t, c.VerifyCAFingerprint(cert, 0))
+			c.CAFingerprint[0] ^= 0xff
+			if err := c.VerifyCAFingerprint(cert, tt.hash); err == nil {
+				t.Error("expected an error")
 			}
 		})
 	}
+
+	c := &SCEPPayloadContent{}
+	if err := c.SetCAFingerprintFromCert(cert, crypto.MD5); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestApplicationAccessPayload(t *testing.T) {
+	pl := NewApplicationAccessPayload("com.example.applicationaccess")
+	pl.AllowedApps = []ApplicationAccessApp{
+		{BundleID: "com.apple.Safari", DisplayName: "Safari"},
+	}
+	pl.DeniedPaths = []string{"/Applications/Chess.app"}
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>whiteList</key><array><dict><key>bundleID</key><string>com.apple.Safari</string>")) {
+		t.Errorf("expected whiteList key, have %s", b)
+	}
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.ApplicationAccessPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestParsePayload(t *testing.T) {
+	scep := NewSCEPPayload("com.example.scep")
+	scep.PayloadContent.URL = "https://scep.example.com/"
+	b, err := plist.Marshal(scep)
+	fatalIf(t, err)
+
+	pld, err := ParsePayload(b)
+	fatalIf(t, err)
+	if !reflect.DeepEqual(pld, scep) {
+		t.Errorf("have %#+v, want %#+v", pld, scep)
+	}
+
+	b, err = plist.Marshal(NewPayload("com.example.unknown", "com.example.unknown"))
+	fatalIf(t, err)
+	pld, err = ParsePayload(b)
+	fatalIf(t, err)
+	if _, ok := pld.(*Payload); !ok {
+		t.Errorf("have %T, want *Payload", pld)
+	}
+
+	b, err = plist.Marshal(map[string]string{"Foo": "bar"})
+	fatalIf(t, err)
+	if _, err := ParsePayload(b); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestMarshalPayload(t *testing.T) {
+	mdm := NewMDMPayload("com.example.mdm")
+	mdm.PayloadDisplayName = "MDM"
+	mdm.ServerURL = "https://mdm.example.com/mdm"
+	b, err := MarshalPayload(mdm)
+	fatalIf(t, err)
+
+	var m map[string]interface{}
+	fatalIf(t, plist.Unmarshal(b, &m))
+	for k, want := range map[string]interface{}{
+		"PayloadType":        PayloadTypeMDM,
+		"PayloadDisplayName": "MDM",
+		"ServerURL":          "https://mdm.example.com/mdm",
+	} {
+		if have := m[k]; have != want {
+			t.Errorf("%s: have %q, want %q", k, have, want)
+		}
+	}
+
+	pld, err := ParsePayload(b)
+	fatalIf(t, err)
+	if !reflect.DeepEqual(pld, mdm) {
+		t.Errorf("have %#+v, want %#+v", pld, mdm)
+	}
+
+	var nilMDM *MDMPayload
+	if _, err := MarshalPayload(nilMDM); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestMDMPayload_Validate(t *testing.T) {
+	pl := NewMDMPayload("com.example.mdm")
+	pl.ServerCapabilities = StringArray{"com.apple.mdm.per-user-connections"}
+	fatalIf(t, pl.Validate())
+
+	pl.CheckOutWhenRemoved = true
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pl.EnableCheckOut("https://mdm.example.com/checkin")
+	fatalIf(t, pl.Validate())
+	if !pl.CheckOutWhenRemoved {
+		t.Error("expected CheckOutWhenRemoved")
+	}
+	if have, want := pl.CheckInURL, "https://mdm.example.com/checkin"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	pl.ServerCapabilities = append(pl.ServerCapabilities, "com.apple.mdm.per-user-connections")
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pl.ServerCapabilities = StringArray{""}
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestUUIDFunc(t *testing.T) {
+	defer func(f func() string) { UUIDFunc = f }(UUIDFunc)
+	n := 0
+	UUIDFunc = func() string {
+		n++
+		return fmt.Sprintf("00000000-0000-0000-0000-%012d", n)
+	}
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	if have, want := p.PayloadUUID, "00000000-0000-0000-0000-000000000001"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := p.MDMPayload().PayloadUUID, "00000000-0000-0000-0000-000000000002"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
+
+func TestSubjectAltNameValidate(t *testing.T) {
+	san := &SubjectAltName{
+		DNSNames:    MultiString{"example.com", "*.example.com", "host-1"},
+		RFC822Names: MultiString{"user@example.com"},
+		URIs:        MultiString{"https://example.com/device"},
+	}
+	fatalIf(t, san.Validate())
+
+	san.DNSNames = append(san.DNSNames, "-bad.example.com", "under_score.example.com")
+	san.RFC822Names = append(san.RFC822Names, "User <user@example.com>")
+	san.URIs = append(san.URIs, "example.com/device")
+	err := san.Validate()
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("have %v, want Errors", err)
+	}
+	if have, want := len(errs), 4; have != want {
+		t.Errorf("have %d errors, want %d", have, want)
+	}
+
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadContent.SubjectAltName = san
+	if err := scep.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+	acme := NewACMECertificatePayload("com.example.profile.acme")
+	acme.SubjectAltName = san
+	if err := acme.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestPayloadTypeMismatch(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	_, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	scep.PayloadType = PayloadTypeACME
+	_, err = plist.Marshal(p)
+	var mismatch *PayloadTypeMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("have %v, want PayloadTypeMismatchError", err)
+	}
+	if have, want := mismatch.ExpectedType, PayloadTypeSCEP; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if _, err := MarshalPayload(scep); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestOnUnknown(t *testing.T) {
+	var unknown []string
+	OnUnknown = func(context, key string) {
+		unknown = append(unknown, context+": "+key)
+	}
+	defer func() { OnUnknown = nil }()
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	p.AddPayload(NewPayload("com.example.unknown", "com.example.profile.unknown"))
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	_, err = ParseProfile(b)
+	fatalIf(t, err)
+
+	want := []string{"PayloadType: com.example.unknown"}
+	if !reflect.DeepEqual(unknown, want) {
+		t.Errorf("have %v, want %v", unknown, want)
+	}
+}
+
+func TestSCEPPayloadCAName(t *testing.T) {
+	pl := NewSCEPPayload("com.example.profile.scep")
+	pl.PayloadDisplayName = "Device Identity"
+	pl.PayloadContent.URL = "https://scep.example.com/scep"
+	b, err := plist.Marshal(pl)
+	fatalIf(t, err)
+	if bytes.Contains(b, []byte("<key>Name</key>")) {
+		t.Error("expected empty Name to be omitted")
+	}
+	if err := pl.PayloadContent.RequireName(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pl.SetCAName("ca-1")
+	if have, want := pl.CAName(), "ca-1"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	fatalIf(t, pl.PayloadContent.RequireName())
+	b, err = plist.Marshal(pl)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>Name</key><string>ca-1</string>")) {
+		t.Error("expected Name key")
+	}
+	if have, want := pl.PayloadDisplayName, "Device Identity"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
 }
@@ -0,0 +1,37 @@
+package cfgprofiles
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Identity decrypts PayloadContent with Password and returns the
+// certificate and private key of the PKCS #12 identity. The first
+// certificate of the PKCS #12 content is its leaf certificate.
+func (pl *CertificatePKCS12Payload) Identity() (*x509.Certificate, crypto.PrivateKey, error) {
+	key, cert, _, err := pkcs12.DecodeChain(pl.PayloadContent, pl.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// SetIdentity encodes cert, its private key and the certificates in
+// chain as PKCS #12 PayloadContent encrypted with password using enc,
+// and sets Password. Use pkcs12.Modern, or pkcs12.Legacy for devices
+// which do not support AES encrypted PKCS #12 content.
+func (pl *CertificatePKCS12Payload) SetIdentity(enc *pkcs12.Encoder, cert *x509.Certificate, key crypto.PrivateKey, chain []*x509.Certificate, password string) error {
+	if enc == nil {
+		return errors.New("nil PKCS #12 encoder")
+	}
+	b, err := enc.Encode(key, cert, chain, password)
+	if err != nil {
+		return err
+	}
+	pl.PayloadContent = b
+	pl.Password = password
+	return nil
+}
@@ -0,0 +1,98 @@
+package cfgprofiles
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestCertificatePKCS12PayloadIdentity(t *testing.T) {
+	cert, key := newTestSigner(t, "PKCS12 Test")
+	ca, _ := newTestSigner(t, "PKCS12 CA")
+
+	pl := NewCertificatePKCS12Payload("com.example.pkcs12")
+	fatalIf(t, pl.SetIdentity(pkcs12.Modern, cert, key, []*x509.Certificate{ca}, "s3cret"))
+	if have, want := pl.Password, "s3cret"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	fatalIf(t, pl.Validate())
+
+	cert2, key2, err := pl.Identity()
+	fatalIf(t, err)
+	if !cert2.Equal(cert) {
+		t.Error("expected certificate to match")
+	}
+	if !key.Equal(key2) {
+		t.Error("expected private key to match")
+	}
+	_, _, chain, err := pkcs12.DecodeChain(pl.PayloadContent, pl.Password)
+	fatalIf(t, err)
+	if len(chain) != 1 || !chain[0].Equal(ca) {
+		t.Error("expected the chain to be encoded")
+	}
+
+	pl.Password = "wrong"
+	if _, _, err := pl.Identity(); err == nil {
+		t.Error("expected an error")
+	}
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	// without a password the user is prompted on install
+	pl.Password = ""
+	fatalIf(t, pl.Validate())
+}
+
+func TestCertificatePKCS12PayloadRSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	fatalIf(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "PKCS12 RSA Test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	fatalIf(t, err)
+	cert, err := x509.ParseCertificate(der)
+	fatalIf(t, err)
+
+	pl := NewCertificatePKCS12Payload("com.example.pkcs12")
+	fatalIf(t, pl.SetIdentity(pkcs12.Legacy, cert, key, nil, "s3cret"))
+	c, err := pl.Certificate()
+	fatalIf(t, err)
+	if !c.Equal(cert) {
+		t.Error("expected certificate to match")
+	}
+
+	if err := pl.SetIdentity(nil, cert, key, nil, "s3cret"); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestCertificatePKCS12PayloadPBES2(t *testing.T) {
+	// created with OpenSSL 3 defaults: AES-256-CBC, PBKDF2 and SHA-256 MAC
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "identity-aes.p12"))
+	fatalIf(t, err)
+	pl := NewCertificatePKCS12Payload("com.example.pkcs12")
+	pl.PayloadContent = b
+	pl.Password = "secret"
+	cert, key, err := pl.Identity()
+	fatalIf(t, err)
+	if have, want := cert.Subject.CommonName, "PKCS12 Test"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if _, ok := key.(*ecdsa.PrivateKey); !ok {
+		t.Errorf("have %T, want *ecdsa.PrivateKey", key)
+	}
+}
@@ -0,0 +1,112 @@
+package cfgprofiles
+
+import "errors"
+
+// PPPC Authorization values.
+const (
+	PPPCAuthorizationAllow = "Allow"
+	PPPCAuthorizationDeny  = "Deny"
+	// PPPCAuthorizationAllowStandardUser lets standard users enable the
+	// service. It is only valid for the ListenEvent and ScreenCapture
+	// services.
+	PPPCAuthorizationAllowStandardUser = "AllowStandardUserToSetSystemService"
+)
+
+// PPPCAuthorization is the Authorization of a PPPC service entry. It
+// unmarshals from a string or from the boolean of the legacy Allowed key,
+// true being Allow and false being Deny, and always marshals as a string.
+type PPPCAuthorization string
+
+// UnmarshalPlist unmarshals a string or a boolean.
+func (a *PPPCAuthorization) UnmarshalPlist(f func(interface{}) error) error {
+	var s string
+	if err := f(&s); err == nil {
+		*a = PPPCAuthorization(s)
+		return nil
+	}
+	var allowed bool
+	if err := f(&allowed); err != nil {
+		return errors.New("PPPC authorization is neither a string nor a boolean")
+	}
+	*a = pppcAuthorizationFromBool(allowed)
+	return nil
+}
+
+// pppcAuthorizationFromBool converts a legacy Allowed value.
+func pppcAuthorizationFromBool(allowed bool) PPPCAuthorization {
+	if allowed {
+		return PPPCAuthorizationAllow
+	}
+	return PPPCAuthorizationDeny
+}
+
+// PPPCServiceEntry is an app or executable's entry for a service of the
+// PPPCPayload.
+// See https://developer.apple.com/documentation/devicemanagement/privacypreferencespolicycontrol/services/identity
+type PPPCServiceEntry struct {
+	Identifier                string
+	IdentifierType            string // Possible values: bundleID, path
+	CodeRequirement           string
+	StaticCode                bool              `plist:",omitempty"`
+	Authorization             PPPCAuthorization `plist:",omitempty"`
+	Comment                   string            `plist:",omitempty"`
+	AEReceiverIdentifier      string            `plist:",omitempty"`
+	AEReceiverIdentifierType  string            `plist:",omitempty"`
+	AEReceiverCodeRequirement string            `plist:",omitempty"`
+}
+
+// pppcServiceEntry has the fields of PPPCServiceEntry without its custom
+// unmarshalling.
+type pppcServiceEntry PPPCServiceEntry
+
+// UnmarshalPlist unmarshals e, upgrading the legacy boolean Allowed key
+// to Authorization if Authorization is not present.
+func (e *PPPCServiceEntry) UnmarshalPlist(f func(interface{}) error) error {
+	var entry pppcServiceEntry
+	if err := f(&entry); err != nil {
+		return err
+	}
+	if entry.Authorization == "" {
+		legacy := struct{ Allowed *bool }{}
+		if err := f(&legacy); err != nil {
+			return err
+		}
+		if legacy.Allowed != nil {
+			entry.Authorization = pppcAuthorizationFromBool(*legacy.Allowed)
+		}
+	}
+	*e = PPPCServiceEntry(entry)
+	return nil
+}
+
+// PPPCPayload represents the "com.apple.TCC.configuration-profile-policy" PayloadType.
+// Services maps service names such as SystemPolicyAllFiles or
+// AppleEvents to the entries for that service.
+// See https://developer.apple.com/documentation/devicemanagement/privacypreferencespolicycontrol
+type PPPCPayload struct {
+	Payload
+	Services map[string][]PPPCServiceEntry
+}
+
+// NewPPPCPayload creates a new payload with identifier i
+func NewPPPCPayload(i string) *PPPCPayload {
+	return &PPPCPayload{
+		Payload:  *NewPayload(PayloadTypePPPC, i),
+		Services: make(map[string][]PPPCServiceEntry),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypePPPC.
+func (pl *PPPCPayload) ExpectedPayloadType() string {
+	return PayloadTypePPPC
+}
+
+// PPPCPayloads returns a slice of all payloads of that type
+func (p *Profile) PPPCPayloads() (plds []*PPPCPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*PPPCPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestPPPCPayloadLegacyAllowed(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "pppc-legacy.mobileconfig"))
+	fatalIf(t, err)
+	p, err := ParseProfile(b)
+	fatalIf(t, err)
+	pls := p.PPPCPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	services := pls[0].Services
+
+	for _, test := range []struct {
+		service string
+		want    PPPCAuthorization
+	}{
+		{"SystemPolicyAllFiles", PPPCAuthorizationAllow},
+		{"Camera", PPPCAuthorizationDeny},
+		{"ScreenCapture", PPPCAuthorizationAllowStandardUser},
+	} {
+		entries := services[test.service]
+		if len(entries) != 1 {
+			t.Fatalf("%s: have %d entries, want 1", test.service, len(entries))
+		}
+		if have := entries[0].Authorization; have != test.want {
+			t.Errorf("%s: have %q, want %q", test.service, have, test.want)
+		}
+	}
+	entry := services["SystemPolicyAllFiles"][0]
+	if !entry.StaticCode {
+		t.Error("expected StaticCode")
+	}
+	if have, want := entry.Comment, "Backup agent"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	// the modern string form is marshaled
+	b, err = plist.Marshal(p)
+	fatalIf(t, err)
+	if bytes.Contains(b, []byte("<key>Allowed</key>")) {
+		t.Error("expected legacy Allowed key to be upgraded")
+	}
+	if !bytes.Contains(b, []byte("<key>Authorization</key><string>Deny</string>")) {
+		t.Error("expected Authorization key")
+	}
+}
@@ -3,11 +3,21 @@
 package cfgprofiles
 
 import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
 	"time"
+
+	"github.com/micromdm/plist"
 )
 
 // Profile represents an Apple Configuration Profile.
 // See https://developer.apple.com/documentation/devicemanagement/toplevel
+//
+// The order of payloads in PayloadContent is preserved exactly when
+// marshaling and unmarshaling, as some installers process payloads
+// sequentially.
 type Profile struct {
 	Payload
 	PayloadContent           []payloadWrapper
@@ -15,7 +25,7 @@ type Profile struct {
 	PayloadRemovalDisallowed bool              `plist:",omitempty"`
 	PayloadScope             string            `plist:",omitempty"`
 	PayloadDate              *time.Time        `plist:",omitempty"`
-	DurationUntilRemoval     float32           `plist:",omitempty"`
+	DurationUntilRemoval     float32           `plist:",omitempty"` // seconds
 	ConsentText              map[string]string `plist:",omitempty"`
 	EncryptedPayloadContent  []byte            `plist:",omitempty"`
 	HasRemovalPasscode       bool              `plist:",omitempty"`
@@ -27,8 +37,58 @@ type Profile struct {
 // NewProfile creates a new Configuration Profile struct with identifier i
 func NewProfile(i string) *Profile {
 	return &Profile{
-		Payload: *NewPayload("Configuration", i),
+		Payload: *NewPayload(PayloadTypeConfiguration, i),
+	}
+}
+
+// NewSCEPEnrollmentProfile creates a new MDM enrollment profile with
+// identifier i containing scep and mdm. The MDM IdentityCertificateUUID
+// is set to the PayloadUUID of scep. Empty payload identifiers are set to
+// i followed by ".scep" and ".mdm"; other identifiers are kept. An error
+// is returned if either payload is nil or if the profile does not pass
+// Validate and ValidateEnrollment.
+func NewSCEPEnrollmentProfile(i string, scep *SCEPPayload, mdm *MDMPayload) (*Profile, error) {
+	if scep == nil || mdm == nil {
+		return nil, errors.New("enrollment profile requires SCEP and MDM payloads")
+	}
+	p := NewProfile(i)
+	if scep.PayloadIdentifier == "" {
+		scep.PayloadIdentifier = i + ".scep"
+	}
+	if mdm.PayloadIdentifier == "" {
+		mdm.PayloadIdentifier = i + ".mdm"
+	}
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	p.AddPayload(scep)
+	p.AddPayload(mdm)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.ValidateEnrollment(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// profile has the fields of Profile without its UnmarshalPlist method.
+type profile Profile
+
+// UnmarshalPlist unmarshals a profile, accepting a PayloadVersion written
+// as a string or real.
+func (p *Profile) UnmarshalPlist(f func(interface{}) error) error {
+	return unmarshalPayloadVersion(f, (*profile)(p))
+}
+
+// SetDurationUntilRemoval sets DurationUntilRemoval to d in whole
+// seconds, rounded to the nearest second. Durations longer than about 194
+// days (1<<24 seconds) may be rounded further to fit a float32. A d of
+// zero or less clears it.
+func (p *Profile) SetDurationUntilRemoval(d time.Duration) {
+	if d <= 0 {
+		p.DurationUntilRemoval = 0
+		return
 	}
+	p.DurationUntilRemoval = float32(d.Round(time.Second) / time.Second)
 }
 
 // AddPayload adds a payload struct to the profile. Properly wraps the type for
@@ -39,3 +99,198 @@ func (p *Profile) AddPayload(pld interface{}) {
 		payloadWrapper{Payload: pld},
 	)
 }
+
+// SetOrganization sets the PayloadOrganization of the profile and of
+// all of its payloads to org.
+func (p *Profile) SetOrganization(org string) {
+	p.PayloadOrganization = org
+	p.PropagateOrganization()
+}
+
+// PropagateOrganization copies the profile's PayloadOrganization to all
+// of its payloads.
+func (p *Profile) PropagateOrganization() {
+	for _, pc := range p.PayloadContent {
+		if pld := CommonPayload(pc.Payload); pld != nil {
+			pld.PayloadOrganization = p.PayloadOrganization
+		}
+	}
+}
+
+// AddCertificateChain adds each certificate in chain to the profile as a
+// separate PKCS1 certificate payload and returns their PayloadUUIDs in
+// the same order. The display name of each payload is set to the common
+// name of the certificate.
+func (p *Profile) AddCertificateChain(chain []*x509.Certificate) []string {
+	uuids := make([]string, 0, len(chain))
+	for _, cert := range chain {
+		pld := NewCertificatePKCS1Payload("")
+		pld.PayloadIdentifier = p.PayloadIdentifier + "." + PayloadTypeCertificatePKCS1 + "." + pld.PayloadUUID
+		pld.PayloadDisplayName = cert.Subject.CommonName
+		if pld.PayloadDisplayName == "" {
+			pld.PayloadDisplayName = "Certificate"
+		}
+		pld.PayloadContent = cert.Raw
+		p.AddPayload(pld)
+		uuids = append(uuids, pld.PayloadUUID)
+	}
+	return uuids
+}
+
+// SetRemovalPasscode sets the passcode required to remove the profile,
+// adding a profile removal password payload if needed.
+func (p *Profile) SetRemovalPasscode(passcode string) {
+	plds := p.ProfileRemovalPasswordPayloads()
+	if len(plds) < 1 {
+		pld := NewProfileRemovalPasswordPayload("")
+		pld.PayloadIdentifier = p.PayloadIdentifier + "." + PayloadTypeProfileRemovalPassword + "." + pld.PayloadUUID
+		p.AddPayload(pld)
+		plds = append(plds, pld)
+	}
+	for _, pld := range plds {
+		pld.RemovalPassword = passcode
+	}
+}
+
+// SetDates sets PayloadDate, PayloadExpirationDate and RemovalDate from
+// RFC 3339 strings. An empty string clears the date so it is omitted.
+// No dates are changed if any string fails to parse.
+func (p *Profile) SetDates(payload, expiration, removal string) error {
+	var dates [3]*time.Time
+	for i, s := range []string{payload, expiration, removal} {
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		dates[i] = &t
+	}
+	p.PayloadDate, p.PayloadExpirationDate, p.RemovalDate = dates[0], dates[1], dates[2]
+	return nil
+}
+
+// Dates returns PayloadDate, PayloadExpirationDate and RemovalDate as
+// RFC 3339 strings. Dates which are not set are returned as empty strings.
+func (p *Profile) Dates() (payload, expiration, removal string) {
+	format := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	return format(p.PayloadDate), format(p.PayloadExpirationDate), format(p.RemovalDate)
+}
+
+// PayloadByUUID returns the payload with PayloadUUID u or nil if not found.
+func (p *Profile) PayloadByUUID(u string) interface{} {
+	for _, pc := range p.PayloadContent {
+		if pld := CommonPayload(pc.Payload); pld != nil && pld.PayloadUUID == u {
+			return pc.Payload
+		}
+	}
+	return nil
+}
+
+// PayloadTypes returns the distinct PayloadType values of the profile's
+// payloads in order of first appearance.
+func (p *Profile) PayloadTypes() (types []string) {
+	seen := make(map[string]bool)
+	for _, pc := range p.PayloadContent {
+		pld := CommonPayload(pc.Payload)
+		if pld == nil || seen[pld.PayloadType] {
+			continue
+		}
+		seen[pld.PayloadType] = true
+		types = append(types, pld.PayloadType)
+	}
+	return
+}
+
+// HasPayloadType reports whether the profile contains a payload of PayloadType t.
+func (p *Profile) HasPayloadType(t string) bool {
+	for _, pc := range p.PayloadContent {
+		if pld := CommonPayload(pc.Payload); pld != nil && pld.PayloadType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProfile unmarshals the profile in b. Unlike calling plist.Unmarshal
+// directly any panic in the property list decoder caused by malformed
+// input is recovered and returned as an error.
+func ParseProfile(b []byte) (*Profile, error) {
+	p := &Profile{}
+	if err := unmarshalProfile(b, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// unmarshalProfile unmarshals the profile in b into p, recovering any
+// panic in the property list decoder as an error.
+func unmarshalProfile(b []byte, p *Profile) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed profile: %v", r)
+		}
+	}()
+	return plist.Unmarshal(b, p)
+}
+
+// NewProfileFromFile reads the profile at path for use as a template for
+// new profiles. The profile and all of its payloads are given new
+// PayloadUUIDs (see [Profile.RegenerateUUIDs]) so that every profile
+// created from the same file is distinct. A typical workflow is:
+//
+//	p, err := NewProfileFromFile("base.mobileconfig")
+//	if err != nil {
+//		return err
+//	}
+//	p.PayloadDisplayName = "Device Profile"
+//	// ... edit payloads ...
+//	if err := p.Validate(); err != nil {
+//		return err
+//	}
+//	b, err := plist.MarshalIndent(p, "\t")
+//	if err != nil {
+//		return err
+//	}
+//	return os.WriteFile("device.mobileconfig", b, 0644)
+func NewProfileFromFile(path string) (*Profile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParseProfile(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	p.RegenerateUUIDs()
+	return p, nil
+}
+
+// UnmarshalStrict unmarshals the profile in b into p like ParseProfile
+// but returns an error if any payload has a PayloadType that does not
+// match a specific payload struct. Unmodeled certificate payload types
+// parsed as a CertificateGenericPayload are also reported. A single
+// unknown payload results in an *UnknownPayloadTypeError; several result
+// in Errors.
+func UnmarshalStrict(b []byte, p *Profile) error {
+	if err := unmarshalProfile(b, p); err != nil {
+		return err
+	}
+	var errs Errors
+	for i, pc := range p.PayloadContent {
+		switch pc.Payload.(type) {
+		case *Payload, *CertificateGenericPayload:
+			errs = append(errs, &UnknownPayloadTypeError{
+				PayloadType: CommonPayload(pc.Payload).PayloadType,
+				Index:       i,
+			})
+		}
+	}
+	return errs.errOrNil()
+}
@@ -1,12 +1,15 @@
 package cfgprofiles
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/micromdm/plist"
 )
@@ -190,3 +193,386 @@ func fatalIf(t *testing.T, err error) {
 		t.Fatal(err)
 	}
 }
+
+func TestProfileSetOrganization(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewSCEPPayload("com.example.profile.scep"))
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+
+	p.SetOrganization("Example Inc.")
+
+	if have, want := p.PayloadOrganization, "Example Inc."; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	for _, pc := range p.PayloadContent {
+		if have, want := CommonPayload(pc.Payload).PayloadOrganization, "Example Inc."; have != want {
+			t.Errorf("have %q, want %q", have, want)
+		}
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	plBytes, err := ioutil.ReadFile(filepath.Join("testdata", "1.mobileconfig"))
+	fatalIf(t, err)
+	fatalIf(t, UnmarshalStrict(plBytes, &Profile{}))
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewPayload("com.example.unknown1", "com.example.profile.unknown1"))
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	p.AddPayload(NewPayload("com.example.unknown2", "com.example.profile.unknown2"))
+	plBytes, err = plist.Marshal(p)
+	fatalIf(t, err)
+
+	err = UnmarshalStrict(plBytes, &Profile{})
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, have %v", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("have %d errors, want %d", len(errs), 2)
+	}
+	var uerr *UnknownPayloadTypeError
+	if !errors.As(errs[1], &uerr) {
+		t.Fatalf("expected *UnknownPayloadTypeError, have %v", errs[1])
+	}
+	if uerr.PayloadType != "com.example.unknown2" || uerr.Index != 2 {
+		t.Errorf("have %q/%d, want %q/%d", uerr.PayloadType, uerr.Index, "com.example.unknown2", 2)
+	}
+	if !errors.As(err, &uerr) {
+		t.Error("expected errors.As to find *UnknownPayloadTypeError in Errors")
+	}
+}
+
+func TestAddCertificateChain(t *testing.T) {
+	cert := GetCertData(t)
+	p := NewProfile("com.example.profile")
+
+	uuids := p.AddCertificateChain([]*x509.Certificate{cert, cert})
+
+	pls := p.CertificatePKCS1Payloads()
+	if len(pls) != 2 || len(uuids) != 2 {
+		t.Fatal("payload count is not 2")
+	}
+	for i, pl := range pls {
+		if pl.PayloadUUID != uuids[i] {
+			t.Errorf("have %q, want %q", pl.PayloadUUID, uuids[i])
+		}
+		if have, want := pl.PayloadDisplayName, "Entrust Root Certification Authority - G2"; have != want {
+			t.Errorf("have %q, want %q", have, want)
+		}
+		if want := "com.example.profile.com.apple.security.pkcs1." + pl.PayloadUUID; pl.PayloadIdentifier != want {
+			t.Errorf("have %q, want %q", pl.PayloadIdentifier, want)
+		}
+	}
+	if uuids[0] == uuids[1] {
+		t.Error("expected distinct UUIDs")
+	}
+}
+
+func TestPayloadContentOrder(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	p.AddPayload(NewPayload("com.example.unknown", "com.example.profile.unknown"))
+	p.AddPayload(NewCertificatePKCS1Payload("com.example.profile.pkcs1"))
+	p.AddPayload(NewSCEPPayload("com.example.profile.scep"))
+	p.AddPayload(NewCertificatePKCS1Payload("com.example.profile.pkcs1.2"))
+
+	want := make([]string, 0, len(p.PayloadContent))
+	for _, pc := range p.PayloadContent {
+		want = append(want, CommonPayload(pc.Payload).PayloadUUID)
+	}
+
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	for i := 0; i < 2; i++ {
+		p = &Profile{}
+		fatalIf(t, plist.Unmarshal(b, p))
+		have := make([]string, 0, len(p.PayloadContent))
+		for _, pc := range p.PayloadContent {
+			have = append(have, CommonPayload(pc.Payload).PayloadUUID)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("have %v, want %v", have, want)
+		}
+		b, err = plist.Marshal(p)
+		fatalIf(t, err)
+	}
+}
+
+func TestProfilePayloadTypes(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewCertificatePKCS1Payload("com.example.profile.pkcs1"))
+	p.AddPayload(NewSCEPPayload("com.example.profile.scep"))
+	p.AddPayload(NewCertificatePKCS1Payload("com.example.profile.pkcs1.2"))
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+
+	want := []string{"com.apple.security.pkcs1", "com.apple.security.scep", "com.apple.mdm"}
+	if have := p.PayloadTypes(); !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+	if !p.HasPayloadType("com.apple.mdm") {
+		t.Error("expected profile to have MDM payload")
+	}
+	if p.HasPayloadType("com.apple.security.acme") {
+		t.Error("expected profile not to have ACME payload")
+	}
+}
+
+func TestProfileRemovalPasswordRoundTrip(t *testing.T) {
+	plBytes, err := ioutil.ReadFile(filepath.Join("testdata", "removal-password.mobileconfig"))
+	fatalIf(t, err)
+
+	p := &Profile{}
+	fatalIf(t, plist.Unmarshal(plBytes, p))
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	p = &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p))
+
+	pls := p.ProfileRemovalPasswordPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if have, want := pls[0].RemovalPassword, "s3cret"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	p.SetRemovalPasscode("changed")
+	if len(p.PayloadContent) != 1 {
+		t.Errorf("have %d payloads, want %d", len(p.PayloadContent), 1)
+	}
+	if have, want := pls[0].RemovalPassword, "changed"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	p = NewProfile("com.example.profile")
+	p.SetRemovalPasscode("new")
+	if pls := p.ProfileRemovalPasswordPayloads(); len(pls) != 1 || pls[0].RemovalPassword != "new" {
+		t.Error("expected a removal password payload to be added")
+	}
+}
+
+func TestParseProfileMalformed(t *testing.T) {
+	// truncated binary property list which panics the decoder
+	b := []byte("bplist00\xd1\x01\x02_\x10\x0ePayloadContent\xa1\x03\xd1\x04\x05[PayloadType_\x10\x17com.apple.security.acme\b\v\x1c\x1e!-\x00\x00\x00\x00\x00\x00\x01f\x00\x00\x00\x00\x00\x00\x00\x06\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00G")
+	if _, err := ParseProfile(b); err == nil {
+		t.Error("expected an error")
+	}
+
+	plBytes, err := ioutil.ReadFile(filepath.Join("testdata", "1.mobileconfig"))
+	fatalIf(t, err)
+	p, err := ParseProfile(plBytes)
+	fatalIf(t, err)
+	t.Run("profile", func(t *testing.T) { PKCS1CertProfileTest(p, t) })
+}
+
+func TestMarshalNilPayload(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(nil)
+	if _, err := plist.Marshal(p); err == nil {
+		t.Error("expected an error")
+	}
+
+	p = NewProfile("com.example.profile")
+	p.AddPayload((*MDMPayload)(nil))
+	if _, err := plist.Marshal(p); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestNewProfileFromFile(t *testing.T) {
+	path := filepath.Join("testdata", "1.mobileconfig")
+	base, err := NewProfileFromFile(path)
+	fatalIf(t, err)
+	p, err := NewProfileFromFile(path)
+	fatalIf(t, err)
+
+	if base.PayloadUUID == p.PayloadUUID {
+		t.Error("expected distinct profile UUIDs")
+	}
+	if have, want := p.PayloadIdentifier, base.PayloadIdentifier; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	certs := p.CertificatePKCS1Payloads()
+	if len(certs) != 1 {
+		t.Fatalf("have %d payloads, want 1", len(certs))
+	}
+	if have, notWant := certs[0].PayloadUUID, "8BF53919-B83E-4280-A40C-0407FB6AF341"; have == notWant {
+		t.Errorf("have %q, expected a new UUID", have)
+	}
+
+	p.PayloadDisplayName = "Edited"
+	fatalIf(t, p.Validate())
+	b, err := plist.MarshalIndent(p, "\t")
+	fatalIf(t, err)
+	p2, err := ParseProfile(b)
+	fatalIf(t, err)
+	if have, want := p2.PayloadUUID, p.PayloadUUID; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	if _, err := NewProfileFromFile(filepath.Join("testdata", "does-not-exist")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestPayloadVersionString(t *testing.T) {
+	plBytes, err := ioutil.ReadFile(filepath.Join("testdata", "payloadversion-string.mobileconfig"))
+	fatalIf(t, err)
+
+	p, err := ParseProfile(plBytes)
+	fatalIf(t, err)
+	if have, want := p.PayloadVersion, 1; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	mdms := p.MDMPayloads()
+	if len(mdms) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if have, want := mdms[0].PayloadVersion, 1; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+
+	// always marshaled as an integer
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	if bytes.Contains(b, []byte("<key>PayloadVersion</key><string>")) {
+		t.Error("expected PayloadVersion to be marshaled as an integer")
+	}
+
+	// reals are accepted if they are whole numbers
+	b = bytes.Replace(plBytes, []byte("<string>1</string>"), []byte("<real>2</real>"), -1)
+	p, err = ParseProfile(b)
+	fatalIf(t, err)
+	if have, want := p.PayloadVersion, 2; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	if have, want := p.MDMPayloads()[0].PayloadVersion, 2; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	b = bytes.Replace(plBytes, []byte("<string>1</string>"), []byte("<real>1.5</real>"), 1)
+	if _, err := ParseProfile(b); err == nil {
+		t.Error("expected an error")
+	}
+
+	b = bytes.Replace(plBytes, []byte("<string>1</string>"), []byte("<string>one</string>"), 1)
+	if _, err := ParseProfile(b); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestProfileSetDates(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	fatalIf(t, p.SetDates("2024-01-02T03:04:05Z", "", "2025-01-02T03:04:05+01:00"))
+	if p.PayloadExpirationDate != nil {
+		t.Error("expected nil PayloadExpirationDate")
+	}
+	payload, expiration, removal := p.Dates()
+	for _, tt := range []struct{ have, want string }{
+		{payload, "2024-01-02T03:04:05Z"},
+		{expiration, ""},
+		{removal, "2025-01-02T03:04:05+01:00"},
+	} {
+		if tt.have != tt.want {
+			t.Errorf("have %q, want %q", tt.have, tt.want)
+		}
+	}
+
+	if err := p.SetDates("", "tomorrow", ""); err == nil {
+		t.Error("expected an error")
+	}
+	if p.PayloadDate == nil {
+		t.Error("expected dates to be unchanged after error")
+	}
+
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	if bytes.Contains(b, []byte("PayloadExpirationDate")) {
+		t.Error("expected PayloadExpirationDate to be omitted")
+	}
+}
+
+func TestProfileFirstPayloads(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	if p.MDMPayload() != nil || p.SCEPPayload() != nil || p.ACMEPayload() != nil {
+		t.Error("expected nil payloads")
+	}
+
+	scep1 := NewSCEPPayload("com.example.profile.scep1")
+	p.AddPayload(scep1)
+	p.AddPayload(NewSCEPPayload("com.example.profile.scep2"))
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	p.AddPayload(mdm)
+	acme := NewACMECertificatePayload("com.example.profile.acme")
+	p.AddPayload(acme)
+
+	if p.SCEPPayload() != scep1 {
+		t.Error("expected first SCEP payload")
+	}
+	if p.MDMPayload() != mdm {
+		t.Error("expected MDM payload")
+	}
+	if p.ACMEPayload() != acme {
+		t.Error("expected ACME payload")
+	}
+}
+
+func TestNewSCEPEnrollmentProfile(t *testing.T) {
+	scep := NewSCEPPayload("")
+	scep.PayloadContent.URL = "https://scep.example.com/scep"
+	mdm := NewMDMPayload("com.example.mdm")
+	mdm.ServerURL = "https://mdm.example.com/mdm"
+	mdm.Topic = "com.apple.mgmt.External.example"
+
+	p, err := NewSCEPEnrollmentProfile("com.example.enroll", scep, mdm)
+	fatalIf(t, err)
+	if have, want := mdm.IdentityCertificateUUID, scep.PayloadUUID; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := p.PayloadIdentifier, "com.example.enroll"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := scep.PayloadIdentifier, "com.example.enroll.scep"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := mdm.PayloadIdentifier, "com.example.mdm"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	if _, err := NewSCEPEnrollmentProfile("com.example.enroll", nil, mdm); err == nil {
+		t.Error("expected an error for a nil payload")
+	}
+
+	mdm.CheckOutWhenRemoved = true
+	if _, err := NewSCEPEnrollmentProfile("com.example.enroll", scep, mdm); err == nil {
+		t.Error("expected an error for an invalid payload")
+	}
+}
+
+func TestProfileSetDurationUntilRemoval(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.SetDurationUntilRemoval(365 * 24 * time.Hour)
+	if have, want := p.DurationUntilRemoval, float32(31536000); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	if have, want := p2.DurationUntilRemoval, float32(31536000); have != want {
+		t.Errorf("have %v, want %v seconds", have, want)
+	}
+
+	// durations are rounded to the nearest second
+	p.SetDurationUntilRemoval(90*time.Minute + 400*time.Millisecond)
+	if have, want := p.DurationUntilRemoval, float32(5400); have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	p.SetDurationUntilRemoval(0)
+	b, err = plist.Marshal(p)
+	fatalIf(t, err)
+	if bytes.Contains(b, []byte("DurationUntilRemoval")) {
+		t.Error("expected DurationUntilRemoval to be omitted")
+	}
+}
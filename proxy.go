@@ -0,0 +1,91 @@
+package cfgprofiles
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Proxy types for the ProxyType key.
+const (
+	ProxyTypeNone   = "None"
+	ProxyTypeManual = "Manual"
+	ProxyTypeAuto   = "Auto"
+)
+
+// ProxySettings contains the proxy keys shared by the WiFiPayload and
+// GlobalHTTPProxyPayload. It is embedded so the keys are marshaled at the
+// top level of the payload.
+type ProxySettings struct {
+	ProxyType               string `plist:",omitempty"`
+	ProxyServer             string `plist:",omitempty"`
+	ProxyServerPort         int    `plist:",omitempty"`
+	ProxyUsername           string `plist:",omitempty"`
+	ProxyPassword           string `plist:",omitempty"`
+	ProxyPACURL             string `plist:",omitempty"`
+	ProxyPACFallbackAllowed bool   `plist:",omitempty"`
+}
+
+// SetAutoProxy configures automatic proxy configuration using the PAC file
+// at pacURL. If fallback is true a direct connection is allowed when the
+// PAC file is unreachable. Manual proxy keys are cleared.
+func (s *ProxySettings) SetAutoProxy(pacURL string, fallback bool) {
+	*s = ProxySettings{
+		ProxyType:               ProxyTypeAuto,
+		ProxyPACURL:             pacURL,
+		ProxyPACFallbackAllowed: fallback,
+	}
+}
+
+// validateProxy checks the proxy keys for invalid combinations.
+func (s *ProxySettings) validateProxy() error {
+	switch s.ProxyType {
+	case "", ProxyTypeNone, ProxyTypeManual, ProxyTypeAuto:
+	default:
+		return fmt.Errorf("invalid ProxyType: %q", s.ProxyType)
+	}
+	if s.ProxyPACFallbackAllowed && s.ProxyPACURL == "" {
+		return errors.New("ProxyPACFallbackAllowed requires ProxyPACURL")
+	}
+	if s.ProxyPACURL != "" && s.ProxyType != ProxyTypeAuto {
+		return errors.New("ProxyPACURL requires Auto ProxyType")
+	}
+	if s.ProxyType == ProxyTypeManual && s.ProxyServer == "" {
+		return errors.New("manual proxy requires ProxyServer")
+	}
+	return nil
+}
+
+// GlobalHTTPProxyPayload represents the "com.apple.proxy.http.global" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/globalhttpproxy
+type GlobalHTTPProxyPayload struct {
+	Payload
+	ProxySettings
+	ProxyCaptiveLoginAllowed bool `plist:",omitempty"`
+}
+
+// NewGlobalHTTPProxyPayload creates a new payload with identifier i
+func NewGlobalHTTPProxyPayload(i string) *GlobalHTTPProxyPayload {
+	return &GlobalHTTPProxyPayload{
+		Payload: *NewPayload(PayloadTypeGlobalHTTPProxy, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeGlobalHTTPProxy.
+func (pl *GlobalHTTPProxyPayload) ExpectedPayloadType() string {
+	return PayloadTypeGlobalHTTPProxy
+}
+
+// Validate checks the proxy keys for invalid combinations.
+func (pl *GlobalHTTPProxyPayload) Validate() error {
+	return pl.validateProxy()
+}
+
+// GlobalHTTPProxyPayloads returns a slice of all payloads of that type
+func (p *Profile) GlobalHTTPProxyPayloads() (plds []*GlobalHTTPProxyPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*GlobalHTTPProxyPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
@@ -0,0 +1,38 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestSetAutoProxy(t *testing.T) {
+	wifi := NewWiFiPayload("com.example.wifi")
+	wifi.ProxyServer = "proxy.example.com"
+	wifi.SetAutoProxy("https://example.com/proxy.pac", true)
+	fatalIf(t, wifi.Validate())
+	if wifi.ProxyServer != "" {
+		t.Error("expected manual proxy keys to be cleared")
+	}
+
+	global := NewGlobalHTTPProxyPayload("com.example.proxy")
+	global.SetAutoProxy("https://example.com/proxy.pac", true)
+	fatalIf(t, global.Validate())
+
+	b, err := plist.Marshal(global)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>ProxyPACFallbackAllowed</key><true/>")) {
+		t.Errorf("expected top level ProxyPACFallbackAllowed key, have %s", b)
+	}
+
+	global.ProxyPACURL = ""
+	if err := global.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	global.ProxySettings = ProxySettings{ProxyType: ProxyTypeManual, ProxyPACURL: "https://example.com/proxy.pac"}
+	if err := global.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,122 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Sensitive is implemented by payloads which carry secrets.
+type Sensitive interface {
+	// SensitiveFields returns the property list keys of the payload's
+	// secrets. Keys are matched at any depth of the payload, including
+	// nested dictionaries.
+	SensitiveFields() []string
+}
+
+// SensitiveFields implements Sensitive.
+func (pl *SCEPPayload) SensitiveFields() []string {
+	return []string{"Challenge"}
+}
+
+// SensitiveFields implements Sensitive.
+func (pl *CertificatePKCS12Payload) SensitiveFields() []string {
+	return []string{"Password"}
+}
+
+// SensitiveFields implements Sensitive.
+func (pl *CertificateGenericPayload) SensitiveFields() []string {
+	return []string{"Password"}
+}
+
+// SensitiveFields implements Sensitive.
+func (pl *WiFiPayload) SensitiveFields() []string {
+	return []string{"Password", "ProxyPassword", "UserPassword"}
+}
+
+// SensitiveFields implements Sensitive.
+func (pl *VPNPayload) SensitiveFields() []string {
+	return []string{"AuthPassword", "SharedSecret"}
+}
+
+// SensitiveFields implements Sensitive.
+func (pl *ProfileRemovalPasswordPayload) SensitiveFields() []string {
+	return []string{"RemovalPassword"}
+}
+
+// SensitiveFields implements Sensitive.
+func (pl *GlobalHTTPProxyPayload) SensitiveFields() []string {
+	return []string{"ProxyPassword"}
+}
+
+// Redacted returns a copy of the profile with secrets such as passwords
+// and SCEP challenges removed. It is suitable for logging or storage.
+// The keys removed from each payload are those returned by its
+// SensitiveFields method if it implements Sensitive.
+func (p *Profile) Redacted() *Profile {
+	r := p.Clone()
+	for _, pc := range r.PayloadContent {
+		s, ok := pc.Payload.(Sensitive)
+		if !ok {
+			continue
+		}
+		keys := make(map[string]bool)
+		for _, k := range s.SensitiveFields() {
+			keys[k] = true
+		}
+		redactKeys(reflect.ValueOf(pc.Payload), keys)
+	}
+	return r
+}
+
+// redactKeys sets the struct fields in v whose property list key is in
+// keys to their zero value and deletes such map entries.
+func redactKeys(v reflect.Value, keys map[string]bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			redactKeys(v.Elem(), keys)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+			if keys[plistKey(t.Field(i))] {
+				f.Set(reflect.Zero(f.Type()))
+				continue
+			}
+			redactKeys(f, keys)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			redactKeys(v.Index(i), keys)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if keys[iter.Key().String()] {
+				v.SetMapIndex(iter.Key(), reflect.Value{})
+				continue
+			}
+			// only reference values can be redacted in place
+			switch e := iter.Value(); e.Kind() {
+			case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+				redactKeys(e, keys)
+			}
+		}
+	}
+}
+
+// plistKey returns the property list key of struct field f.
+func plistKey(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("plist"), ",")[0]
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package cfgprofiles
+
+import "testing"
+
+func TestProfileRedacted(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadContent.Challenge = "secret"
+	p.AddPayload(scep)
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	wifi.Password = "secret"
+	wifi.EAPClientConfiguration = &EAPClientConfiguration{UserPassword: "secret"}
+	p.AddPayload(wifi)
+
+	r := p.Redacted()
+
+	if have := r.SCEPPayloads()[0].PayloadContent.Challenge; have != "" {
+		t.Errorf("have %q, want empty challenge", have)
+	}
+	rwifi := r.WiFiPayloads()[0]
+	if rwifi.Password != "" || rwifi.EAPClientConfiguration.UserPassword != "" {
+		t.Error("expected empty Wi-Fi passwords")
+	}
+	if scep.PayloadContent.Challenge != "secret" || wifi.Password != "secret" || wifi.EAPClientConfiguration.UserPassword != "secret" {
+		t.Error("expected original profile to be unchanged")
+	}
+}
+
+type sensitiveTestPayload struct {
+	Payload
+	Token    string
+	Settings map[string]interface{}
+}
+
+func (pl *sensitiveTestPayload) SensitiveFields() []string {
+	return []string{"Token", "APIKey"}
+}
+
+func TestProfileRedactedSensitive(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	vpn := &VPNPayload{Payload: *NewPayload("com.apple.vpn.managed", "com.example.profile.vpn")}
+	vpn.IKEv2 = &VPNIKEv2{AuthPassword: "secret", SharedSecret: "secret"}
+	p.AddPayload(vpn)
+	custom := &sensitiveTestPayload{
+		Payload:  *NewPayload("com.example.custom", "com.example.profile.custom"),
+		Token:    "secret",
+		Settings: map[string]interface{}{"APIKey": "secret", "Host": "example.com"},
+	}
+	p.AddPayload(custom)
+
+	r := p.Redacted()
+
+	rvpn := r.VPNPayloads()[0]
+	if rvpn.IKEv2.AuthPassword != "" || rvpn.IKEv2.SharedSecret != "" {
+		t.Error("expected empty IKEv2 secrets")
+	}
+	rcustom := r.PayloadContent[1].Payload.(*sensitiveTestPayload)
+	if rcustom.Token != "" {
+		t.Errorf("have %q, want empty token", rcustom.Token)
+	}
+	if _, ok := rcustom.Settings["APIKey"]; ok {
+		t.Error("expected APIKey to be deleted")
+	}
+	if have, want := rcustom.Settings["Host"], "example.com"; have != want {
+		t.Errorf("have %v, want %q", have, want)
+	}
+	if vpn.IKEv2.AuthPassword != "secret" || custom.Token != "secret" || custom.Settings["APIKey"] != "secret" {
+		t.Error("expected original profile to be unchanged")
+	}
+}
@@ -0,0 +1,158 @@
+package cfgprofiles
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// SecretRef references a secret, such as a password or SCEP challenge,
+// within a payload of a profile. Field is the dot separated path of
+// property list keys to the secret within the payload, e.g.
+// "PayloadContent.Challenge" or "EAPClientConfiguration.UserPassword".
+// Array elements are referenced by their index.
+type SecretRef struct {
+	PayloadUUID string
+	Field       string
+	Value       string
+}
+
+// Secrets returns a reference to each non-empty secret of the profile's
+// payloads, in payload order. The secrets of a payload are the string
+// values of the keys returned by its SensitiveFields method if it
+// implements Sensitive (see Redacted).
+func (p *Profile) Secrets() (refs []SecretRef) {
+	for _, pc := range p.PayloadContent {
+		s, ok := pc.Payload.(Sensitive)
+		if !ok {
+			continue
+		}
+		c := CommonPayload(pc.Payload)
+		if c == nil {
+			continue
+		}
+		walkSecrets(reflect.ValueOf(pc.Payload), sensitiveKeys(s), "", func(field, value string, _ func(string)) bool {
+			refs = append(refs, SecretRef{PayloadUUID: c.PayloadUUID, Field: field, Value: value})
+			return false
+		})
+	}
+	return
+}
+
+// SetSecret replaces the secret referenced by ref with value. The
+// payload is found by ref.PayloadUUID and the secret by ref.Field;
+// ref.Value is ignored. It returns an error if the secret is not found.
+func (p *Profile) SetSecret(ref SecretRef, value string) error {
+	pld := p.PayloadByUUID(ref.PayloadUUID)
+	if pld == nil {
+		return fmt.Errorf("payload not found: %s", ref.PayloadUUID)
+	}
+	s, ok := pld.(Sensitive)
+	if !ok {
+		return fmt.Errorf("payload has no secrets: %s", ref.PayloadUUID)
+	}
+	found := false
+	walkSecrets(reflect.ValueOf(pld), sensitiveKeys(s), "", func(field, _ string, set func(string)) bool {
+		if field != ref.Field {
+			return false
+		}
+		set(value)
+		found = true
+		return true
+	})
+	if !found {
+		return fmt.Errorf("secret %s not found in payload %s", ref.Field, ref.PayloadUUID)
+	}
+	return nil
+}
+
+// sensitiveKeys returns the SensitiveFields of s as a set.
+func sensitiveKeys(s Sensitive) map[string]bool {
+	keys := make(map[string]bool)
+	for _, k := range s.SensitiveFields() {
+		keys[k] = true
+	}
+	return keys
+}
+
+// walkSecrets calls fn with the path, value and a setter of each
+// non-empty string in v whose property list key is in keys. Embedded
+// structs and fields tagged "-", such as ExtraFields, do not add to the
+// path as their keys are marshaled into the enclosing dictionary. Map
+// entries are visited in key order. The walk stops when fn returns true.
+func walkSecrets(v reflect.Value, keys map[string]bool, path string, fn func(field, value string, set func(string)) bool) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return walkSecrets(v.Elem(), keys, path, fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f, sf := v.Field(i), t.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+			if sf.Anonymous || sf.Tag.Get("plist") == "-" {
+				if walkSecrets(f, keys, path, fn) {
+					return true
+				}
+				continue
+			}
+			key := plistKey(sf)
+			if keys[key] && f.Kind() == reflect.String {
+				if f.Len() > 0 && fn(joinField(path, key), f.String(), f.SetString) {
+					return true
+				}
+				continue
+			}
+			if walkSecrets(f, keys, joinField(path, key), fn) {
+				return true
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if walkSecrets(v.Index(i), keys, joinField(path, strconv.Itoa(i)), fn) {
+				return true
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		mk := v.MapKeys()
+		sort.Slice(mk, func(i, j int) bool { return mk[i].String() < mk[j].String() })
+		for _, k := range mk {
+			e := v.MapIndex(k)
+			if e.Kind() == reflect.Interface && !e.IsNil() && e.Elem().Kind() == reflect.String {
+				e = e.Elem()
+			}
+			if keys[k.String()] && e.Kind() == reflect.String {
+				set := func(s string) {
+					v.SetMapIndex(k, reflect.ValueOf(s).Convert(e.Type()))
+				}
+				if e.Len() > 0 && fn(joinField(path, k.String()), e.String(), set) {
+					return true
+				}
+				continue
+			}
+			// only reference values can be set in place
+			switch e.Kind() {
+			case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+				if walkSecrets(e, keys, joinField(path, k.String()), fn) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// joinField appends key to the dot separated path.
+func joinField(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
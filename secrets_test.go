@@ -0,0 +1,68 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfileSecrets(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadContent.Challenge = "challenge"
+	p.AddPayload(scep)
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	wifi.EAPClientConfiguration = &EAPClientConfiguration{UserPassword: "wifi"}
+	p.AddPayload(wifi)
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	vpn := &VPNPayload{Payload: *NewPayload("com.apple.vpn.managed", "com.example.profile.vpn")}
+	vpn.IKEv2 = &VPNIKEv2{SharedSecret: "shared"}
+	p.AddPayload(vpn)
+
+	want := []SecretRef{
+		{scep.PayloadUUID, "PayloadContent.Challenge", "challenge"},
+		{wifi.PayloadUUID, "EAPClientConfiguration.UserPassword", "wifi"},
+		{vpn.PayloadUUID, "IKEv2.SharedSecret", "shared"},
+	}
+	refs := p.Secrets()
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("have %v, want %v", refs, want)
+	}
+
+	for _, ref := range refs {
+		fatalIf(t, p.SetSecret(ref, "rotated"))
+	}
+	if have, want := scep.PayloadContent.Challenge, "rotated"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := wifi.EAPClientConfiguration.UserPassword, "rotated"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := vpn.IKEv2.SharedSecret, "rotated"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	if err := p.SetSecret(SecretRef{PayloadUUID: wifi.PayloadUUID, Field: "Password"}, "x"); err == nil {
+		t.Error("expected an error for an unset secret")
+	}
+	if err := p.SetSecret(SecretRef{PayloadUUID: "missing", Field: "Password"}, "x"); err == nil {
+		t.Error("expected an error for a missing payload")
+	}
+}
+
+func TestWalkSecretsMap(t *testing.T) {
+	pl := &sensitiveTestPayload{
+		Settings: map[string]interface{}{"APIKey": "key", "Host": "example.com"},
+	}
+	var fields []string
+	walkSecrets(reflect.ValueOf(pl), sensitiveKeys(pl), "", func(field, value string, set func(string)) bool {
+		fields = append(fields, field)
+		set("rotated")
+		return false
+	})
+	if have, want := fields, []string{"Settings.APIKey"}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+	if have, want := pl.Settings["APIKey"], "rotated"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
@@ -0,0 +1,272 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/micromdm/plist"
+	"github.com/smallstep/pkcs7"
+)
+
+// ErrWeakSignatureAlgorithm is returned by VerifyProfileSignature for
+// signatures using a SHA-1 digest.
+var ErrWeakSignatureAlgorithm = errors.New("weak signature digest algorithm")
+
+// Canonicalize returns the profile marshaled as a non-indented XML
+// property list with dictionary keys sorted. Semantically equal profiles
+// canonicalize to identical bytes which makes the result suitable for
+// signing and hashing.
+func (p *Profile) Canonicalize() ([]byte, error) {
+	return plist.Marshal(p)
+}
+
+// ContentHash returns a hex encoded SHA-256 hash of the canonical form of
+// the profile with the PayloadUUIDs of the profile and its payloads
+// replaced by their position. Profiles which differ only in their UUIDs,
+// such as those regenerated from the same template, hash equal.
+// References between payloads are rewritten to match so that which
+// payload is referenced still contributes to the hash, as are
+// PayloadIdentifiers containing a PayloadUUID, such as those created by
+// AddCertificateChain.
+func (p *Profile) ContentHash() (string, error) {
+	c := p.Clone()
+	replaced := make(map[string]string)
+	c.replaceUUIDs(func(u string) string {
+		replaced[u] = fmt.Sprintf("%d", len(replaced)+1)
+		return replaced[u]
+	})
+	replaceIdentifierUUIDs(&c.Payload, replaced)
+	for _, pc := range c.PayloadContent {
+		if pld := CommonPayload(pc.Payload); pld != nil {
+			replaceIdentifierUUIDs(pld, replaced)
+		}
+	}
+	b, err := c.Canonicalize()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replaceIdentifierUUIDs replaces each UUID key of replaced within the
+// PayloadIdentifier of pld with its value. Keys which are not UUIDs are
+// ignored as they may be part of an unrelated identifier.
+func replaceIdentifierUUIDs(pld *Payload, replaced map[string]string) {
+	for u, r := range replaced {
+		if _, err := uuid.Parse(u); err == nil {
+			pld.PayloadIdentifier = strings.ReplaceAll(pld.PayloadIdentifier, u, r)
+		}
+	}
+}
+
+// SignProfile signs the canonical form of profile p (see Canonicalize)
+// with the certificate cert and its private key and returns a DER encoded
+// CMS SignedData structure containing the profile. The signing
+// certificate and the certificates in chain, such as the issuing
+// intermediates, are included in the SignedData certificates so that
+// devices can build a path to a trusted root.
+//
+// The enclosed content is the canonical form of the profile at signing
+// time; to compare a profile to the signed content re-canonicalize it.
+func SignProfile(p *Profile, cert *x509.Certificate, key crypto.Signer, chain []*x509.Certificate) ([]byte, error) {
+	return SignProfileWithOptions(p, cert, key, chain, nil)
+}
+
+// SignOptions control which certificates SignProfileWithOptions includes
+// in the SignedData.
+type SignOptions struct {
+	// OmitSigner omits the signing certificate. Verifiers, including
+	// VerifyProfileSignature, must then obtain it elsewhere.
+	OmitSigner bool
+
+	// OmitChain omits the chain certificates.
+	OmitChain bool
+}
+
+// SignProfileWithOptions is like SignProfile but omits certificates as
+// set in opts, e.g. for size-sensitive deployments where devices already
+// have the chain. A nil opts includes all certificates.
+func SignProfileWithOptions(p *Profile, cert *x509.Certificate, key crypto.Signer, chain []*x509.Certificate, opts *SignOptions) ([]byte, error) {
+	content, err := p.Canonicalize()
+	if err != nil {
+		return nil, err
+	}
+	sd, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		return nil, err
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSigner(cert, key, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.OmitSigner {
+		// AddSigner always includes the signing certificate; move the
+		// signed structure to a SignedData without it.
+		omitted, err := pkcs7.NewSignedData(content)
+		if err != nil {
+			return nil, err
+		}
+		*omitted.GetSignedData() = *sd.GetSignedData()
+		sd = omitted
+	}
+	if opts == nil || !opts.OmitChain {
+		for _, c := range chain {
+			sd.AddCertificate(c)
+		}
+	}
+	return sd.Finish()
+}
+
+// isSignedData reports whether b looks like a DER encoded CMS structure
+// rather than a property list.
+func isSignedData(b []byte) bool {
+	return len(b) > 0 && b[0] == 0x30 // SEQUENCE
+}
+
+// signedContent returns the encapsulated content of the DER encoded CMS
+// SignedData structure in b. The signature is not verified.
+func signedContent(b []byte) ([]byte, error) {
+	p7, err := parseSignedData(b)
+	if err != nil {
+		return nil, err
+	}
+	return p7.Content, nil
+}
+
+// parseSignedData parses the DER encoded CMS SignedData structure in b
+// which must have enclosed content.
+func parseSignedData(b []byte) (*pkcs7.PKCS7, error) {
+	p7, err := pkcs7.Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signed data: %w", err)
+	}
+	if len(p7.Content) == 0 {
+		return nil, errors.New("signed data has no content")
+	}
+	return p7, nil
+}
+
+// ProfileSigners returns the certificates included in the DER encoded
+// CMS SignedData structure b of a signed profile: first the certificates
+// of its signers, then any other (chain) certificates. Signers whose
+// certificate is not included, such as with SignOptions.OmitSigner, are
+// skipped. Neither the signature nor the certificates are verified, so
+// the result is only suitable for display; use VerifyProfileSignature to
+// verify it.
+func ProfileSigners(b []byte) ([]*x509.Certificate, error) {
+	p7, err := parseSignedData(b)
+	if err != nil {
+		return nil, err
+	}
+	var signers []*x509.Certificate
+	isSigner := make(map[*x509.Certificate]bool)
+	for _, si := range p7.Signers {
+		ias := si.IssuerAndSerialNumber
+		cert := signerCertificate(p7.Certificates, ias.IssuerName.FullBytes, ias.SerialNumber)
+		if cert != nil && !isSigner[cert] {
+			isSigner[cert] = true
+			signers = append(signers, cert)
+		}
+	}
+	for _, c := range p7.Certificates {
+		if !isSigner[c] {
+			signers = append(signers, c)
+		}
+	}
+	return signers, nil
+}
+
+// VerifyProfileSignature verifies the DER encoded CMS SignedData
+// structure b, such as created by SignProfile, and returns the signing
+// certificate. The signature is checked to be valid for the enclosed
+// content and the signing certificate's public key; the certificate
+// itself is not verified, use its Verify method with the trusted roots.
+// Signatures with a SHA-1 digest are rejected with an error wrapping
+// ErrWeakSignatureAlgorithm.
+//
+// If p is not nil it is also checked that p is the signed profile. A
+// profile which was unwrapped from the signed data and then modified
+// results in a *SignatureContentMismatchError, distinguishing a profile
+// altered since signing from an invalid signature. Profiles are compared
+// by their canonical form (see Canonicalize) so the enclosed profile need
+// not be canonical.
+func VerifyProfileSignature(b []byte, p *Profile) (*x509.Certificate, error) {
+	p7, err := parseSignedData(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(p7.Signers) != 1 {
+		return nil, fmt.Errorf("have %d signers, want 1", len(p7.Signers))
+	}
+	if alg := p7.Signers[0].DigestAlgorithm.Algorithm; isSHA1(alg) {
+		return nil, fmt.Errorf("%w: %v", ErrWeakSignatureAlgorithm, alg)
+	}
+	if err := p7.Verify(); err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	cert := p7.GetOnlySigner()
+	if cert == nil {
+		return nil, errors.New("signer certificate not found")
+	}
+
+	if p == nil {
+		return cert, nil
+	}
+	canonical, err := p.Canonicalize()
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(canonical, p7.Content) {
+		return cert, nil // the signed digest is of p's canonical form
+	}
+	signed, err := ParseProfile(p7.Content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing signed profile: %w", err)
+	}
+	signedCanonical, err := signed.Canonicalize()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(canonical, signedCanonical) {
+		return cert, &SignatureContentMismatchError{Signer: cert}
+	}
+	return cert, nil
+}
+
+// isSHA1 reports whether the digest algorithm OID oid is, or is
+// verified by pkcs7 as, SHA-1.
+func isSHA1(oid asn1.ObjectIdentifier) bool {
+	for _, sha1 := range []asn1.ObjectIdentifier{
+		pkcs7.OIDDigestAlgorithmSHA1,
+		pkcs7.OIDDigestAlgorithmECDSASHA1,
+		pkcs7.OIDDigestAlgorithmDSA,
+		pkcs7.OIDDigestAlgorithmDSASHA1,
+		pkcs7.OIDEncryptionAlgorithmRSA,
+	} {
+		if oid.Equal(sha1) {
+			return true
+		}
+	}
+	return false
+}
+
+// signerCertificate returns the certificate in certs with the DER
+// encoded issuer and serial number, or nil if it is not included.
+func signerCertificate(certs []*x509.Certificate, issuer []byte, serial *big.Int) *x509.Certificate {
+	for _, c := range certs {
+		if bytes.Equal(c.RawIssuer, issuer) && c.SerialNumber.Cmp(serial) == 0 {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/micromdm/plist"
+	"github.com/smallstep/pkcs7"
+)
+
+func TestCanonicalize(t *testing.T) {
+	build := func(consent [][2]string) *Profile {
+		p := NewProfile("com.example.profile")
+		p.PayloadUUID = "2689BE77-60CE-4588-83F7-7CDC494DB1AA"
+		p.ConsentText = make(map[string]string)
+		for _, kv := range consent {
+			p.ConsentText[kv[0]] = kv[1]
+		}
+		pl := NewAppConfigPayload("com.example.profile.appconfig")
+		pl.PayloadUUID = "8BF53919-B83E-4280-A40C-0407FB6AF341"
+		pl.Configuration = make(map[string]interface{})
+		for _, kv := range consent {
+			pl.Configuration[kv[1]] = kv[0]
+		}
+		p.AddPayload(pl)
+		return p
+	}
+	a, err := build([][2]string{{"default", "a"}, {"en", "b"}, {"de", "c"}}).Canonicalize()
+	fatalIf(t, err)
+	b, err := build([][2]string{{"de", "c"}, {"en", "b"}, {"default", "a"}}).Canonicalize()
+	fatalIf(t, err)
+	if !bytes.Equal(a, b) {
+		t.Errorf("expected identical canonical bytes:\n%s\n%s", a, b)
+	}
+	if bytes.Contains(a, []byte("\n\t")) {
+		t.Error("expected non-indented output")
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	p.AddPayload(mdm)
+
+	uuid := p.PayloadUUID
+	a, err := p.ContentHash()
+	fatalIf(t, err)
+	if p.PayloadUUID != uuid {
+		t.Error("expected profile to be unmodified")
+	}
+
+	p2 := p.Clone()
+	p2.RegenerateUUIDs()
+	b, err := p2.ContentHash()
+	fatalIf(t, err)
+	if a != b {
+		t.Errorf("have %q, want %q", b, a)
+	}
+
+	p2.PayloadDisplayName = "Changed"
+	b, err = p2.ContentHash()
+	fatalIf(t, err)
+	if a == b {
+		t.Error("expected hashes to differ")
+	}
+}
+
+func TestContentHashIdentifierUUIDs(t *testing.T) {
+	cert, _ := newTestSigner(t, "Example CA")
+	hash := func() string {
+		p := NewProfile("com.example.profile")
+		p.AddCertificateChain([]*x509.Certificate{cert})
+		p.SetRemovalPasscode("secret")
+		h, err := p.ContentHash()
+		fatalIf(t, err)
+		return h
+	}
+	if a, b := hash(), hash(); a != b {
+		t.Errorf("have %q, want %q", b, a)
+	}
+}
+
+// newTestSigner creates a self-signed ECDSA certificate and key.
+func newTestSigner(t *testing.T, cn string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	fatalIf(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	fatalIf(t, err)
+	cert, err := x509.ParseCertificate(der)
+	fatalIf(t, err)
+	return cert, key
+}
+
+func TestSignProfile(t *testing.T) {
+	cert, key := newTestSigner(t, "Profile Signer")
+	p := NewProfile("com.example.profile")
+
+	b, err := SignProfile(p, cert, key, nil)
+	fatalIf(t, err)
+
+	p7, err := pkcs7.Parse(b)
+	fatalIf(t, err)
+	content, err := p.Canonicalize()
+	fatalIf(t, err)
+	if !bytes.Equal(p7.Content, content) {
+		t.Error("expected signed content to be the canonical profile")
+	}
+	if len(p7.Signers) != 1 {
+		t.Errorf("have %d signers, want %d", len(p7.Signers), 1)
+	}
+	if !p7.Signers[0].DigestAlgorithm.Algorithm.Equal(pkcs7.OIDDigestAlgorithmSHA256) {
+		t.Errorf("have digest algorithm %v, want SHA-256", p7.Signers[0].DigestAlgorithm.Algorithm)
+	}
+}
+
+func TestVerifyProfileSignature(t *testing.T) {
+	cert, key := newTestSigner(t, "Profile Signer")
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	b, err := SignProfile(p, cert, key, nil)
+	fatalIf(t, err)
+
+	signer, err := VerifyProfileSignature(b, nil)
+	fatalIf(t, err)
+	if !signer.Equal(cert) {
+		t.Error("expected signer certificate")
+	}
+	_, err = VerifyProfileSignature(b, p)
+	fatalIf(t, err)
+
+	// an equal profile which is not in canonical form
+	content, err := signedContent(b)
+	fatalIf(t, err)
+	unwrapped, err := ParseProfile(content)
+	fatalIf(t, err)
+	_, err = VerifyProfileSignature(b, unwrapped)
+	fatalIf(t, err)
+
+	// modified after unwrapping
+	unwrapped.PayloadDisplayName = "Modified"
+	_, err = VerifyProfileSignature(b, unwrapped)
+	var mismatch *SignatureContentMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("have %v, want SignatureContentMismatchError", err)
+	}
+	if !mismatch.Signer.Equal(cert) {
+		t.Error("expected signer certificate")
+	}
+
+	// tampered signed content
+	tampered := bytes.Replace(b, []byte("com.example.profile.mdm"), []byte("com.example.profile.xyz"), 1)
+	if _, err := VerifyProfileSignature(tampered, nil); err == nil || errors.As(err, &mismatch) {
+		t.Errorf("have %v, want invalid signature", err)
+	}
+
+	// signed by another key
+	other, _ := newTestSigner(t, "Other Signer")
+	_, otherKey := newTestSigner(t, "Other Signer")
+	b, err = SignProfile(p, other, otherKey, nil)
+	fatalIf(t, err)
+	if _, err := VerifyProfileSignature(b, p); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestProfileSigners(t *testing.T) {
+	cert, key := newTestSigner(t, "Profile Signer")
+	chain, _ := newTestSigner(t, "Intermediate CA")
+	p := NewProfile("com.example.profile")
+	b, err := SignProfile(p, cert, key, []*x509.Certificate{chain})
+	fatalIf(t, err)
+
+	signers, err := ProfileSigners(b)
+	fatalIf(t, err)
+	if len(signers) != 2 {
+		t.Fatalf("have %d certificates, want 2", len(signers))
+	}
+	if !signers[0].Equal(cert) || !signers[1].Equal(chain) {
+		t.Error("expected signer certificate followed by chain")
+	}
+
+	// signer certificate omitted
+	b, err = SignProfileWithOptions(p, cert, key, []*x509.Certificate{chain}, &SignOptions{OmitSigner: true})
+	fatalIf(t, err)
+	signers, err = ProfileSigners(b)
+	fatalIf(t, err)
+	if len(signers) != 1 || !signers[0].Equal(chain) {
+		t.Errorf("have %d certificates, want chain only", len(signers))
+	}
+
+	if _, err := ProfileSigners([]byte("<plist/>")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSignProfileChain(t *testing.T) {
+	cert, key := newTestSigner(t, "Profile Signer")
+	intermediate, _ := newTestSigner(t, "Intermediate CA")
+	root, _ := newTestSigner(t, "Root CA")
+	chain := []*x509.Certificate{intermediate, root}
+	p := NewProfile("com.example.profile")
+
+	for _, tt := range []struct {
+		name string
+		opts *SignOptions
+		want []*x509.Certificate
+	}{
+		{"all", nil, []*x509.Certificate{cert, intermediate, root}},
+		{"omit chain", &SignOptions{OmitChain: true}, []*x509.Certificate{cert}},
+		{"omit signer", &SignOptions{OmitSigner: true}, chain},
+		{"omit all", &SignOptions{OmitSigner: true, OmitChain: true}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := SignProfileWithOptions(p, cert, key, chain, tt.opts)
+			fatalIf(t, err)
+			p7, err := pkcs7.Parse(b)
+			fatalIf(t, err)
+			certs := p7.Certificates
+			if len(certs) != len(tt.want) {
+				t.Fatalf("have %d certificates, want %d", len(certs), len(tt.want))
+			}
+			for i := range certs {
+				if !certs[i].Equal(tt.want[i]) {
+					t.Errorf("certificate %d: have %q, want %q", i, certs[i].Subject.CommonName, tt.want[i].Subject.CommonName)
+				}
+			}
+			if _, err := VerifyProfileSignature(b, p); (err == nil) != (tt.opts == nil || !tt.opts.OmitSigner) {
+				t.Errorf("unexpected verification result: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyProfileSignatureOpenSSL(t *testing.T) {
+	// created with openssl cms -sign -binary -nodetach from
+	// testdata/removal-password.mobileconfig
+	for _, tt := range []struct {
+		file string
+		cn   string
+	}{
+		{"signed-openssl-rsa.mobileconfig", "OpenSSL RSA Signer"},    // SHA-256
+		{"signed-openssl-noattr.mobileconfig", "OpenSSL RSA Signer"}, // -noattr
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			b, err := ioutil.ReadFile(filepath.Join("testdata", tt.file))
+			fatalIf(t, err)
+			signer, err := VerifyProfileSignature(b, nil)
+			fatalIf(t, err)
+			if have, want := signer.Subject.CommonName, tt.cn; have != want {
+				t.Errorf("have %q, want %q", have, want)
+			}
+
+			src, err := ioutil.ReadFile(filepath.Join("testdata", "removal-password.mobileconfig"))
+			fatalIf(t, err)
+			p := &Profile{}
+			fatalIf(t, plist.Unmarshal(src, p))
+			_, err = VerifyProfileSignature(b, p)
+			fatalIf(t, err)
+
+			// altering the enclosed content invalidates the signature
+			i := bytes.Index(b, []byte("<plist"))
+			if i < 0 {
+				t.Fatal("enclosed profile not found")
+			}
+			tampered := append([]byte{}, b...)
+			tampered[i+1] = 'P'
+			if _, err := VerifyProfileSignature(tampered, nil); err == nil {
+				t.Error("expected an error for tampered content")
+			}
+		})
+	}
+}
+
+func TestVerifyProfileSignatureSHA1(t *testing.T) {
+	// created with openssl cms -sign -binary -nodetach -md sha1
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "signed-openssl-ec-sha1.mobileconfig"))
+	fatalIf(t, err)
+	if _, err := VerifyProfileSignature(b, nil); !errors.Is(err, ErrWeakSignatureAlgorithm) {
+		t.Errorf("have %v, want %v", err, ErrWeakSignatureAlgorithm)
+	}
+}
+
+// newTestIssued creates an ECDSA certificate and key for cn issued by
+// parent, or self-signed if parent is nil.
+func newTestIssued(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	fatalIf(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	fatalIf(t, err)
+	cert, err := x509.ParseCertificate(der)
+	fatalIf(t, err)
+	return cert, key
+}
+
+func TestSignProfileOpenSSLVerify(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not found")
+	}
+	root, rootKey := newTestIssued(t, "Test Root CA", true, nil, nil)
+	intermediate, intermediateKey := newTestIssued(t, "Test Intermediate CA", true, root, rootKey)
+	leaf, leafKey := newTestIssued(t, "Profile Signer", false, intermediate, intermediateKey)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	fatalIf(t, err)
+	rsaTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "RSA Profile Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	rsaDER, err := x509.CreateCertificate(rand.Reader, rsaTmpl, intermediate, rsaKey.Public(), intermediateKey)
+	fatalIf(t, err)
+	rsaLeaf, err := x509.ParseCertificate(rsaDER)
+	fatalIf(t, err)
+
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	content, err := p.Canonicalize()
+	fatalIf(t, err)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "root.pem")
+	fatalIf(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}), 0600))
+
+	for _, tt := range []struct {
+		name string
+		cert *x509.Certificate
+		key  crypto.Signer
+	}{
+		{"ecdsa", leaf, leafKey},
+		{"rsa", rsaLeaf, rsaKey},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// the intermediate is only available from the SignedData
+			b, err := SignProfile(p, tt.cert, tt.key, []*x509.Certificate{intermediate})
+			fatalIf(t, err)
+			in := filepath.Join(dir, tt.name+".der")
+			out := filepath.Join(dir, tt.name+".out")
+			fatalIf(t, ioutil.WriteFile(in, b, 0600))
+			cmd := exec.Command(openssl, "cms", "-verify", "-binary", "-inform", "DER",
+				"-in", in, "-CAfile", caFile, "-purpose", "any", "-out", out)
+			if msg, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("openssl cms -verify: %v: %s", err, msg)
+			}
+			verified, err := ioutil.ReadFile(out)
+			fatalIf(t, err)
+			if !bytes.Equal(verified, content) {
+				t.Error("expected openssl verified content to be the canonical profile")
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package cfgprofiles
+
+import (
+	"sort"
+
+	"github.com/micromdm/plist"
+)
+
+// PayloadSize is the encoded size of a payload in a profile.
+type PayloadSize struct {
+	Index       int // position in the profile's PayloadContent
+	PayloadType string
+	PayloadUUID string
+	Size        int // encoded size in bytes
+}
+
+// EncodedSize returns the size in bytes of the canonical form of the
+// profile (see Canonicalize). Signing and indentation add to the size of
+// the distributed profile.
+func (p *Profile) EncodedSize() (int, error) {
+	b, err := p.Canonicalize()
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// LargestPayloads returns the n payloads of the profile with the largest
+// encoded size, largest first. All payloads are returned if n is less
+// than one or greater than the number of payloads. Payloads which fail to
+// marshal are skipped.
+func (p *Profile) LargestPayloads(n int) []PayloadSize {
+	var sizes []PayloadSize
+	for i, pc := range p.PayloadContent {
+		b, err := plist.Marshal(&payloadWrapper{Payload: pc.Payload})
+		if err != nil {
+			continue
+		}
+		ps := PayloadSize{Index: i, Size: len(b)}
+		if pld := CommonPayload(pc.Payload); pld != nil {
+			ps.PayloadType = pld.PayloadType
+			ps.PayloadUUID = pld.PayloadUUID
+		}
+		sizes = append(sizes, ps)
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].Size > sizes[j].Size
+	})
+	if n > 0 && n < len(sizes) {
+		sizes = sizes[:n]
+	}
+	return sizes
+}
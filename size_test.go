@@ -0,0 +1,40 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProfileEncodedSize(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	cert := NewCertificatePKCS1Payload("com.example.profile.cert")
+	cert.PayloadContent = bytes.Repeat([]byte{0x30}, 4096)
+	p.AddPayload(cert)
+	p.AddPayload(NewSCEPPayload("com.example.profile.scep"))
+
+	b, err := p.Canonicalize()
+	fatalIf(t, err)
+	size, err := p.EncodedSize()
+	fatalIf(t, err)
+	if have, want := size, len(b); have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+
+	largest := p.LargestPayloads(2)
+	if len(largest) != 2 {
+		t.Fatalf("have %d payloads, want 2", len(largest))
+	}
+	if have, want := largest[0].PayloadUUID, cert.PayloadUUID; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := largest[0].Index, 1; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	if largest[0].Size < 4096 || largest[1].Size > largest[0].Size {
+		t.Errorf("unexpected sizes %v", largest)
+	}
+	if have, want := len(p.LargestPayloads(0)), 3; have != want {
+		t.Errorf("have %d payloads, want %d", have, want)
+	}
+}
@@ -0,0 +1,37 @@
+package cfgprofiles
+
+import "sort"
+
+// payloadSortRank returns the position of payload pld in the default
+// payload order of SortPayloads.
+func payloadSortRank(pld interface{}) int {
+	switch pld.(type) {
+	case CertificatePayload:
+		return 0
+	case *SCEPPayload, *ACMECertificatePayload:
+		return 1
+	case *MDMPayload:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// SortPayloads reorders the payloads of the profile: certificate
+// payloads first, then SCEP and ACME identity payloads, then the MDM
+// payload, then all other payloads. Payloads of the same rank keep
+// their relative order.
+func (p *Profile) SortPayloads() {
+	p.SortPayloadsFunc(func(a, b interface{}) bool {
+		return payloadSortRank(a) < payloadSortRank(b)
+	})
+}
+
+// SortPayloadsFunc reorders the payloads of the profile by less, which
+// reports whether payload a sorts before payload b. Payloads which sort
+// equal keep their relative order.
+func (p *Profile) SortPayloadsFunc(less func(a, b interface{}) bool) {
+	sort.SliceStable(p.PayloadContent, func(i, j int) bool {
+		return less(p.PayloadContent[i].Payload, p.PayloadContent[j].Payload)
+	})
+}
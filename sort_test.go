@@ -0,0 +1,51 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfileSortPayloads(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.AddPayload(NewWiFiPayload("com.example.profile.wifi"))
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm"))
+	p.AddPayload(NewSCEPPayload("com.example.profile.scep"))
+	p.AddPayload(NewCertificateRootPayload("com.example.profile.root"))
+	p.AddPayload(NewVPNPayload("com.example.profile.vpn"))
+	p.AddPayload(NewCertificatePEMPayload("com.example.profile.pem"))
+
+	identifiers := func() (ids []string) {
+		for _, pc := range p.PayloadContent {
+			ids = append(ids, CommonPayload(pc.Payload).PayloadIdentifier)
+		}
+		return
+	}
+
+	p.SortPayloads()
+	want := []string{
+		"com.example.profile.root",
+		"com.example.profile.pem",
+		"com.example.profile.scep",
+		"com.example.profile.mdm",
+		"com.example.profile.wifi",
+		"com.example.profile.vpn",
+	}
+	if have := identifiers(); !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	p.SortPayloadsFunc(func(a, b interface{}) bool {
+		return CommonPayload(a).PayloadIdentifier > CommonPayload(b).PayloadIdentifier
+	})
+	want = []string{
+		"com.example.profile.wifi",
+		"com.example.profile.vpn",
+		"com.example.profile.scep",
+		"com.example.profile.root",
+		"com.example.profile.pem",
+		"com.example.profile.mdm",
+	}
+	if have := identifiers(); !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+}
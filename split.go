@@ -0,0 +1,33 @@
+package cfgprofiles
+
+import "reflect"
+
+// Split returns one profile per payload of p, e.g. to find which payload
+// causes an installation to fail. Each profile is a copy of the top-level
+// keys of p with a new PayloadUUID, a PayloadIdentifier derived from p and
+// the payload's PayloadUUID, and a copy of the single payload.
+//
+// References to other payloads, such as the MDM IdentityCertificateUUID,
+// cannot be satisfied in a single payload profile and are deliberately
+// dropped from the copies.
+func (p *Profile) Split() []*Profile {
+	meta := *p
+	meta.PayloadContent = nil
+	meta.EncryptedPayloadContent = nil
+	meta.IsEncrypted = false
+
+	var profiles []*Profile
+	for _, pc := range p.PayloadContent {
+		sp := meta.Clone()
+		sp.PayloadUUID = newUUID()
+		pld := deepCopy(reflect.ValueOf(pc.Payload)).Interface()
+		if c := CommonPayload(pld); c != nil {
+			sp.PayloadIdentifier = p.PayloadIdentifier + "." + c.PayloadUUID
+			own := c.PayloadUUID
+			dropUUIDRefs(pld, func(u string) bool { return u == own })
+		}
+		sp.AddPayload(pld)
+		profiles = append(profiles, sp)
+	}
+	return profiles
+}
@@ -0,0 +1,44 @@
+package cfgprofiles
+
+import "testing"
+
+func TestProfileSplit(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.PayloadDisplayName = "Example"
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	mdm.ServerURLPinningCertificateUUIDs = []string{scep.PayloadUUID}
+	p.AddPayload(mdm)
+
+	profiles := p.Split()
+	if len(profiles) != 2 {
+		t.Fatalf("have %d profiles, want 2", len(profiles))
+	}
+	for i, sp := range profiles {
+		if len(sp.PayloadContent) != 1 {
+			t.Errorf("profile %d: have %d payloads, want 1", i, len(sp.PayloadContent))
+		}
+		if sp.PayloadUUID == p.PayloadUUID {
+			t.Errorf("profile %d: expected a new PayloadUUID", i)
+		}
+		if have, want := sp.PayloadDisplayName, "Example"; have != want {
+			t.Errorf("have %q, want %q", have, want)
+		}
+	}
+	if have, want := profiles[1].PayloadIdentifier, "com.example.profile."+mdm.PayloadUUID; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	splitMDM := profiles[1].MDMPayloads()[0]
+	if splitMDM == mdm {
+		t.Error("expected a copy of the payload")
+	}
+	if splitMDM.IdentityCertificateUUID != "" || len(splitMDM.ServerURLPinningCertificateUUIDs) != 0 {
+		t.Error("expected dangling references to be dropped")
+	}
+	if mdm.IdentityCertificateUUID != scep.PayloadUUID {
+		t.Error("expected original payload to be unmodified")
+	}
+}
@@ -0,0 +1,107 @@
+package cfgprofiles
+
+import "github.com/micromdm/plist"
+
+// Extensible SSO extension types for the Type key.
+const (
+	ExtensibleSSOTypeRedirect   = "Redirect"
+	ExtensibleSSOTypeCredential = "Credential"
+)
+
+// kerberosPKINITCertificateUUIDKey is the ExtensionData key of the
+// Kerberos extension referencing its PKINIT identity payload.
+const kerberosPKINITCertificateUUIDKey = "pkinitCertificateUUID"
+
+// Identifiers of Apple's built-in Kerberos SSO extension.
+const (
+	KerberosExtensionIdentifier = "com.apple.AppSSOKerberos.KerberosExtension"
+	KerberosTeamIdentifier      = "apple"
+)
+
+// ExtensibleSSOPayload represents the "com.apple.extensiblesso" PayloadType.
+// ExtensionData is a free-form dictionary specific to the extension.
+// See https://developer.apple.com/documentation/devicemanagement/extensiblesinglesignon
+type ExtensibleSSOPayload struct {
+	Payload
+	ExtensionIdentifier     string
+	TeamIdentifier          string `plist:",omitempty"`
+	Type                    string
+	URLs                    []string               `plist:",omitempty"`
+	Hosts                   []string               `plist:",omitempty"`
+	Realm                   string                 `plist:",omitempty"`
+	DeniedBundleIdentifiers []string               `plist:",omitempty"`
+	ScreenLockedBehavior    string                 `plist:",omitempty"`
+	ExtensionData           map[string]interface{} `plist:",omitempty"`
+}
+
+// NewExtensibleSSOPayload creates a new payload with identifier i
+func NewExtensibleSSOPayload(i string) *ExtensibleSSOPayload {
+	return &ExtensibleSSOPayload{
+		Payload: *NewPayload(PayloadTypeExtensibleSSO, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeExtensibleSSO.
+func (pl *ExtensibleSSOPayload) ExpectedPayloadType() string {
+	return PayloadTypeExtensibleSSO
+}
+
+// ExtensibleSSOPayloads returns a slice of all payloads of that type
+func (p *Profile) ExtensibleSSOPayloads() (plds []*ExtensibleSSOPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*ExtensibleSSOPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
+
+// KerberosExtensionData represents the ExtensionData of the built-in
+// Kerberos SSO extension.
+// See https://developer.apple.com/documentation/devicemanagement/extensiblesinglesignonkerberos/extensiondata
+type KerberosExtensionData struct {
+	AllowAutomaticLogin   *bool  `plist:"allowAutomaticLogin,omitempty"` // default true
+	AllowPasswordChange   *bool  `plist:"allowPasswordChange,omitempty"` // default true
+	IsDefaultRealm        bool   `plist:"isDefaultRealm,omitempty"`
+	PKINITCertificateUUID string `plist:"pkinitCertificateUUID,omitempty"`
+	PrincipalName         string `plist:"principalName,omitempty"`
+	RequireUserPresence   bool   `plist:"requireUserPresence,omitempty"`
+	SiteCode              string `plist:"siteCode,omitempty"`
+}
+
+// SetKerberos configures the payload for the built-in Kerberos SSO
+// extension for realm and hosts. ExtensionData is replaced by the keys
+// of data, which may be nil.
+func (pl *ExtensibleSSOPayload) SetKerberos(realm string, hosts []string, data *KerberosExtensionData) error {
+	ext := make(map[string]interface{})
+	if data != nil {
+		m, err := marshalWithExtra(data, nil)
+		if err != nil {
+			return err
+		}
+		ext = m.(map[string]interface{})
+	}
+	pl.ExtensionIdentifier = KerberosExtensionIdentifier
+	pl.TeamIdentifier = KerberosTeamIdentifier
+	pl.Type = ExtensibleSSOTypeCredential
+	pl.Realm = realm
+	pl.Hosts = hosts
+	pl.ExtensionData = ext
+	return nil
+}
+
+// Kerberos returns the ExtensionData decoded as Kerberos extension data.
+func (pl *ExtensibleSSOPayload) Kerberos() (*KerberosExtensionData, error) {
+	data := &KerberosExtensionData{}
+	if len(pl.ExtensionData) == 0 {
+		return data, nil
+	}
+	b, err := plist.Marshal(pl.ExtensionData)
+	if err != nil {
+		return nil, err
+	}
+	if err := plist.Unmarshal(b, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
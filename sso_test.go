@@ -0,0 +1,47 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestExtensibleSSOPayloadKerberos(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	pl := NewExtensibleSSOPayload("com.example.profile.sso")
+	allow := false
+	data := &KerberosExtensionData{
+		AllowAutomaticLogin: &allow,
+		PrincipalName:       "user",
+	}
+	fatalIf(t, pl.SetKerberos("EXAMPLE.COM", []string{".example.com"}, data))
+	p.AddPayload(pl)
+
+	if have, want := pl.ExtensionData["allowAutomaticLogin"], false; have != want {
+		t.Errorf("have %v, want %v", have, want)
+	}
+	if _, ok := pl.ExtensionData["siteCode"]; ok {
+		t.Error("expected empty siteCode to be omitted")
+	}
+
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.ExtensibleSSOPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if have, want := pls[0].Type, ExtensibleSSOTypeCredential; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := pls[0].Realm, "EXAMPLE.COM"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	data2, err := pls[0].Kerberos()
+	fatalIf(t, err)
+	if !reflect.DeepEqual(data2, data) {
+		t.Errorf("have %#+v, want %#+v", data2, data)
+	}
+}
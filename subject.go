@@ -0,0 +1,54 @@
+package cfgprofiles
+
+// RDN is a single relative distinguished name attribute of a certificate
+// subject, such as {Type: "CN", Value: "device"}. Type is an attribute
+// short name (e.g. "C", "O", "CN") or a dotted OID.
+type RDN struct {
+	Type  string
+	Value string
+}
+
+// RDNSequence is a certificate subject as an ordered list of attributes.
+// It is an easier to read alternative to the [][][]string Subject of the
+// SCEP and ACME payloads.
+type RDNSequence []RDN
+
+// ToSubject converts r to the Subject form used by the SCEP and ACME
+// payloads. Each attribute becomes its own single-valued RDN.
+// An empty r returns nil.
+func (r RDNSequence) ToSubject() [][][]string {
+	if len(r) == 0 {
+		return nil
+	}
+	subject := make([][][]string, 0, len(r))
+	for _, rdn := range r {
+		subject = append(subject, [][]string{{rdn.Type, rdn.Value}})
+	}
+	return subject
+}
+
+// SubjectToRDNSequence converts a SCEP or ACME payload Subject to an
+// RDNSequence. The attributes of multi-valued RDNs are flattened in order.
+// Malformed attributes which are not a type and value pair are skipped.
+func SubjectToRDNSequence(subject [][][]string) RDNSequence {
+	var r RDNSequence
+	for _, set := range subject {
+		for _, attr := range set {
+			if len(attr) != 2 {
+				continue
+			}
+			r = append(r, RDN{Type: attr[0], Value: attr[1]})
+		}
+	}
+	return r
+}
+
+// SetSubject sets the Subject from RDNSequence r.
+func (c *SCEPPayloadContent) SetSubject(r RDNSequence) {
+	c.Subject = r.ToSubject()
+}
+
+// SetSubject sets the Subject from RDNSequence r.
+func (pl *ACMECertificatePayload) SetSubject(r RDNSequence) {
+	pl.Subject = r.ToSubject()
+}
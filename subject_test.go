@@ -0,0 +1,43 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRDNSequence(t *testing.T) {
+	r := RDNSequence{
+		{Type: "C", Value: "US"},
+		{Type: "O", Value: "Example"},
+		{Type: "1.2.5.3", Value: "bar"},
+	}
+	want := [][][]string{
+		{{"C", "US"}},
+		{{"O", "Example"}},
+		{{"1.2.5.3", "bar"}},
+	}
+
+	pl := NewACMECertificatePayload("com.example.acme")
+	pl.SetSubject(r)
+	if !reflect.DeepEqual(pl.Subject, want) {
+		t.Errorf("have %v, want %v", pl.Subject, want)
+	}
+	if have := SubjectToRDNSequence(pl.Subject); !reflect.DeepEqual(have, r) {
+		t.Errorf("have %v, want %v", have, r)
+	}
+
+	// multi-valued RDNs are flattened and malformed attributes skipped
+	have := SubjectToRDNSequence([][][]string{
+		{{"CN", "device"}, {"OU", "IT"}},
+		{{"invalid"}},
+	})
+	if want := (RDNSequence{{"CN", "device"}, {"OU", "IT"}}); !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	var c SCEPPayloadContent
+	c.SetSubject(nil)
+	if c.Subject != nil {
+		t.Errorf("have %v, want nil", c.Subject)
+	}
+}
@@ -0,0 +1,53 @@
+package cfgprofiles
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var templateVar = regexp.MustCompile(`{{\s*([A-Za-z0-9_.-]+)\s*}}`)
+
+// Template is a base profile used to render per-device profiles.
+// String values of the profile and its payloads may contain {{name}}
+// placeholders which are substituted when rendering.
+type Template struct {
+	Profile *Profile
+}
+
+// NewTemplate creates a new template from base profile p.
+func NewTemplate(p *Profile) *Template {
+	return &Template{Profile: p}
+}
+
+// Render returns a copy of the template profile with {{name}} placeholders
+// in string values replaced by vars[name]. The profile and all payloads
+// are given new PayloadUUIDs and references between payloads are updated
+// to match. An error is returned if a placeholder has no value in vars.
+func (t *Template) Render(vars map[string]string) (*Profile, error) {
+	missing := make(map[string]bool)
+	subst := func(s string) string {
+		return templateVar.ReplaceAllStringFunc(s, func(m string) string {
+			name := templateVar.FindStringSubmatch(m)[1]
+			v, ok := vars[name]
+			if !ok {
+				missing[name] = true
+				return m
+			}
+			return v
+		})
+	}
+	p := copyValue(reflect.ValueOf(t.Profile), subst).Interface().(*Profile)
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("missing template variables: %s", strings.Join(names, ", "))
+	}
+	p.RegenerateUUIDs()
+	return p, nil
+}
@@ -0,0 +1,108 @@
+package cfgprofiles
+
+import "testing"
+
+func TestTemplateRender(t *testing.T) {
+	base := NewProfile("com.example.{{device}}")
+	base.PayloadDisplayName = "Enrollment for {{ device }}"
+	scep := NewSCEPPayload("com.example.{{device}}.scep")
+	scep.PayloadContent.Challenge = "{{challenge}}"
+	scep.PayloadContent.Subject = [][][]string{{{"CN", "{{device}}"}}}
+	base.AddPayload(scep)
+	mdm := NewMDMPayload("com.example.{{device}}.mdm")
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	base.AddPayload(mdm)
+	appConfig := NewAppConfigPayload("com.example.{{device}}.appconfig")
+	appConfig.Configuration = map[string]interface{}{
+		"Names": []interface{}{"{{device}}"},
+	}
+	base.AddPayload(appConfig)
+
+	tmpl := NewTemplate(base)
+	p, err := tmpl.Render(map[string]string{"device": "dev1", "challenge": "secret"})
+	fatalIf(t, err)
+
+	if have, want := p.PayloadIdentifier, "com.example.dev1"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := p.PayloadDisplayName, "Enrollment for dev1"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	rscep := p.SCEPPayloads()[0]
+	if rscep.PayloadContent.Challenge != "secret" || rscep.PayloadContent.Subject[0][0][1] != "dev1" {
+		t.Error("expected SCEP values to be substituted")
+	}
+	if rscep.PayloadUUID == scep.PayloadUUID || p.PayloadUUID == base.PayloadUUID {
+		t.Error("expected new UUIDs")
+	}
+	if have := p.MDMPayloads()[0].IdentityCertificateUUID; have != rscep.PayloadUUID {
+		t.Errorf("have %q, want %q", have, rscep.PayloadUUID)
+	}
+	if have := p.AppConfigPayloads()[0].Configuration["Names"].([]interface{})[0]; have != "dev1" {
+		t.Errorf("have %q, want %q", have, "dev1")
+	}
+
+	// template is unchanged
+	if scep.PayloadContent.Challenge != "{{challenge}}" || mdm.IdentityCertificateUUID != scep.PayloadUUID {
+		t.Error("expected template to be unchanged")
+	}
+
+	if _, err := tmpl.Render(map[string]string{"device": "dev1"}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestTemplateRenderSCEPChallenge(t *testing.T) {
+	base := NewProfile("com.example.{{device}}")
+	scep := NewSCEPPayload("com.example.{{device}}.scep")
+	scep.SetChallenge("static")
+	base.AddPayload(scep)
+	tmpl := NewTemplate(base)
+
+	for _, c := range []string{"one-time-1", "one-time-2"} {
+		p, err := tmpl.Render(map[string]string{"device": "dev1"})
+		fatalIf(t, err)
+		p.SetSCEPChallenge(c)
+		if have, want := p.SCEPPayloads()[0].Challenge(), c; have != want {
+			t.Errorf("have %q, want %q", have, want)
+		}
+	}
+	if have, want := scep.Challenge(), "static"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
+
+func TestTemplateRenderKerberosPKINIT(t *testing.T) {
+	base := NewProfile("com.example.{{device}}")
+	scep := NewSCEPPayload("com.example.{{device}}.scep")
+	base.AddPayload(scep)
+	sso := NewExtensibleSSOPayload("com.example.{{device}}.sso")
+	fatalIf(t, sso.SetKerberos("EXAMPLE.COM", []string{"example.com"}, &KerberosExtensionData{
+		PKINITCertificateUUID: scep.PayloadUUID,
+		PrincipalName:         "{{device}}",
+	}))
+	base.AddPayload(sso)
+	tmpl := NewTemplate(base)
+
+	p, err := tmpl.Render(map[string]string{"device": "dev1"})
+	fatalIf(t, err)
+	rscep := p.SCEPPayloads()[0]
+	if rscep.PayloadUUID == scep.PayloadUUID {
+		t.Error("expected new UUIDs")
+	}
+	data, err := p.ExtensibleSSOPayloads()[0].Kerberos()
+	fatalIf(t, err)
+	if have, want := data.PKINITCertificateUUID, rscep.PayloadUUID; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := data.PrincipalName, "dev1"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+
+	// template is unchanged
+	data, err = sso.Kerberos()
+	fatalIf(t, err)
+	if have, want := data.PKINITCertificateUUID, scep.PayloadUUID; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
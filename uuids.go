@@ -0,0 +1,167 @@
+package cfgprofiles
+
+import "strings"
+
+// uuidRefs calls f with each reference of payload pld to another
+// payload by its PayloadUUID and replaces the reference with the result.
+// Empty references are skipped. All known references are to certificate
+// or identity payloads.
+func uuidRefs(pld interface{}, f func(string) string) {
+	ref := func(u *string) {
+		if *u != "" {
+			*u = f(*u)
+		}
+	}
+	switch pl := pld.(type) {
+	case *WiFiPayload:
+		ref(&pl.PayloadCertificateUUID)
+		if pl.EAPClientConfiguration != nil {
+			anchors := pl.EAPClientConfiguration.PayloadCertificateAnchorUUID
+			for i := range anchors {
+				ref(&anchors[i])
+			}
+		}
+	case *VPNPayload:
+		if pl.VPN != nil {
+			ref(&pl.VPN.PayloadCertificateUUID)
+		}
+		if pl.IKEv2 != nil {
+			ref(&pl.IKEv2.PayloadCertificateUUID)
+		}
+	case *FDERecoveryKeyEscrowPayload:
+		ref(&pl.EncryptCertPayloadUUID)
+	case *MDMPayload:
+		ref(&pl.IdentityCertificateUUID)
+		for i := range pl.ServerURLPinningCertificateUUIDs {
+			ref(&pl.ServerURLPinningCertificateUUIDs[i])
+		}
+		for i := range pl.CheckInURLPinningCertificateUUIDs {
+			ref(&pl.CheckInURLPinningCertificateUUIDs[i])
+		}
+	case *ExtensibleSSOPayload:
+		// the Kerberos PKINIT identity in the free-form ExtensionData
+		if u, ok := pl.ExtensionData[kerberosPKINITCertificateUUIDKey].(string); ok {
+			ref(&u)
+			if u == "" {
+				delete(pl.ExtensionData, kerberosPKINITCertificateUUIDKey)
+			} else {
+				pl.ExtensionData[kerberosPKINITCertificateUUIDKey] = u
+			}
+		}
+	}
+}
+
+// dropUUIDRefs clears the references of payload pld to PayloadUUIDs for
+// which keep returns false. Cleared references are removed from lists.
+func dropUUIDRefs(pld interface{}, keep func(string) bool) {
+	uuidRefs(pld, func(u string) string {
+		if !keep(u) {
+			return ""
+		}
+		return u
+	})
+	switch pl := pld.(type) {
+	case *WiFiPayload:
+		if pl.EAPClientConfiguration != nil {
+			pl.EAPClientConfiguration.PayloadCertificateAnchorUUID = withoutEmpty(pl.EAPClientConfiguration.PayloadCertificateAnchorUUID)
+		}
+	case *MDMPayload:
+		pl.ServerURLPinningCertificateUUIDs = withoutEmpty(pl.ServerURLPinningCertificateUUIDs)
+		pl.CheckInURLPinningCertificateUUIDs = withoutEmpty(pl.CheckInURLPinningCertificateUUIDs)
+	}
+}
+
+// withoutEmpty returns s without empty strings.
+func withoutEmpty(s []string) []string {
+	var out []string
+	for _, v := range s {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// replaceUUIDs sets the PayloadUUID of the profile and all payloads to
+// the result of f and rewrites any payload references to match.
+func (p *Profile) replaceUUIDs(f func(string) string) {
+	m := make(map[string]string)
+	m[p.PayloadUUID] = f(p.PayloadUUID)
+	p.PayloadUUID = m[p.PayloadUUID]
+	for _, pc := range p.PayloadContent {
+		if pld := CommonPayload(pc.Payload); pld != nil {
+			m[pld.PayloadUUID] = f(pld.PayloadUUID)
+			pld.PayloadUUID = m[pld.PayloadUUID]
+		}
+	}
+	for _, pc := range p.PayloadContent {
+		uuidRefs(pc.Payload, func(u string) string {
+			if newUUID, ok := m[u]; ok {
+				return newUUID
+			}
+			return u
+		})
+	}
+}
+
+// NormalizeUUIDs uppercases the PayloadUUID of the profile and all
+// payloads, as Apple's tools do. References to those UUIDs from other
+// payloads are updated to match.
+func (p *Profile) NormalizeUUIDs() {
+	p.replaceUUIDs(strings.ToUpper)
+}
+
+// RegenerateUUIDs assigns new random PayloadUUIDs to the profile and all
+// payloads. References to those UUIDs from other payloads, such as the
+// MDM IdentityCertificateUUID and pinning certificates, are updated to
+// the new values.
+func (p *Profile) RegenerateUUIDs() {
+	p.replaceUUIDs(func(string) string { return newUUID() })
+}
+
+// isCertificatePayload reports whether pld is a payload which installs a
+// certificate or identity that other payloads may reference.
+func isCertificatePayload(pld interface{}) bool {
+	switch pld.(type) {
+	case *CertificatePKCS1Payload, *CertificateRootPayload, *CertificatePEMPayload,
+		*CertificatePKCS12Payload, *CertificateGenericPayload, *SCEPPayload, *ACMECertificatePayload:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReferencedCertificateUUIDs returns the distinct certificate payload UUIDs
+// referenced by the profile's payloads in order of first reference.
+// For example the MDM IdentityCertificateUUID and pinning certificates or
+// the Wi-Fi PayloadCertificateUUID and EAP trust anchors.
+func (p *Profile) ReferencedCertificateUUIDs() (uuids []string) {
+	seen := make(map[string]bool)
+	for _, pc := range p.PayloadContent {
+		uuidRefs(pc.Payload, func(u string) string {
+			if !seen[u] {
+				seen[u] = true
+				uuids = append(uuids, u)
+			}
+			return u
+		})
+	}
+	return
+}
+
+// UnreferencedCertificatePayloads returns the certificate and identity
+// payloads (including SCEP and ACME) not referenced by any other payload.
+// Note that certificates such as trusted roots are commonly installed
+// without being referenced.
+func (p *Profile) UnreferencedCertificatePayloads() (plds []interface{}) {
+	refs := make(map[string]bool)
+	for _, u := range p.ReferencedCertificateUUIDs() {
+		refs[u] = true
+	}
+	for _, pc := range p.PayloadContent {
+		if isCertificatePayload(pc.Payload) && !refs[payloadUUID(pc.Payload)] {
+			plds = append(plds, pc.Payload)
+		}
+	}
+	return
+}
@@ -0,0 +1,125 @@
+package cfgprofiles
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeUUIDs(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.PayloadUUID = "734eeacf-1334-4b65-8e8c-6ac07e9b79e5"
+	scep := NewSCEPPayload("com.example.profile.scep")
+	scep.PayloadUUID = "cbdc6238-feec-4171-8784-98e576bbb814"
+	p.AddPayload(scep)
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	mdm.ServerURLPinningCertificateUUIDs = []string{scep.PayloadUUID, "not-in-profile"}
+	p.AddPayload(mdm)
+
+	p.NormalizeUUIDs()
+
+	if have, want := p.PayloadUUID, "734EEACF-1334-4B65-8E8C-6AC07E9B79E5"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if have, want := scep.PayloadUUID, "CBDC6238-FEEC-4171-8784-98E576BBB814"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if mdm.IdentityCertificateUUID != scep.PayloadUUID {
+		t.Errorf("have %q, want %q", mdm.IdentityCertificateUUID, scep.PayloadUUID)
+	}
+	if mdm.ServerURLPinningCertificateUUIDs[0] != scep.PayloadUUID {
+		t.Errorf("have %q, want %q", mdm.ServerURLPinningCertificateUUIDs[0], scep.PayloadUUID)
+	}
+	if have, want := mdm.ServerURLPinningCertificateUUIDs[1], "not-in-profile"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+}
+
+func TestReferencedCertificateUUIDs(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	ca := NewCertificatePKCS1Payload("com.example.profile.ca")
+	p.AddPayload(ca)
+	unused := NewCertificateRootPayload("com.example.profile.unused")
+	p.AddPayload(unused)
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	mdm.ServerURLPinningCertificateUUIDs = []string{ca.PayloadUUID}
+	p.AddPayload(mdm)
+	wifi := NewWiFiPayload("com.example.profile.wifi")
+	wifi.PayloadCertificateUUID = scep.PayloadUUID
+	wifi.EAPClientConfiguration = &EAPClientConfiguration{
+		PayloadCertificateAnchorUUID: []string{ca.PayloadUUID},
+	}
+	p.AddPayload(wifi)
+
+	want := []string{scep.PayloadUUID, ca.PayloadUUID}
+	if have := p.ReferencedCertificateUUIDs(); !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	plds := p.UnreferencedCertificatePayloads()
+	if len(plds) != 1 || plds[0] != unused {
+		t.Errorf("have %v, want %v", plds, []interface{}{unused})
+	}
+}
+
+func TestRegenerateUUIDs(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	ca := NewCertificatePKCS1Payload("com.example.profile.ca")
+	p.AddPayload(ca)
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	mdm.ServerURLPinningCertificateUUIDs = []string{ca.PayloadUUID}
+	mdm.CheckInURLPinningCertificateUUIDs = []string{ca.PayloadUUID}
+	p.AddPayload(mdm)
+
+	oldProfile, oldSCEP, oldCA, oldMDM := p.PayloadUUID, scep.PayloadUUID, ca.PayloadUUID, mdm.PayloadUUID
+	p.RegenerateUUIDs()
+
+	if p.PayloadUUID == oldProfile || scep.PayloadUUID == oldSCEP || ca.PayloadUUID == oldCA || mdm.PayloadUUID == oldMDM {
+		t.Error("expected all UUIDs to change")
+	}
+	if scep.PayloadUUID != strings.ToUpper(scep.PayloadUUID) {
+		t.Errorf("expected uppercase UUID, have %q", scep.PayloadUUID)
+	}
+	if mdm.IdentityCertificateUUID != scep.PayloadUUID {
+		t.Errorf("have %q, want %q", mdm.IdentityCertificateUUID, scep.PayloadUUID)
+	}
+	if mdm.ServerURLPinningCertificateUUIDs[0] != ca.PayloadUUID || mdm.CheckInURLPinningCertificateUUIDs[0] != ca.PayloadUUID {
+		t.Error("expected pinning references to be updated")
+	}
+}
+
+func TestRegenerateUUIDsKerberosPKINIT(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	identity := NewCertificatePKCS12Payload("com.example.profile.identity")
+	p.AddPayload(identity)
+	sso := NewExtensibleSSOPayload("com.example.profile.sso")
+	fatalIf(t, sso.SetKerberos("EXAMPLE.COM", []string{"example.com"}, &KerberosExtensionData{
+		PKINITCertificateUUID: identity.PayloadUUID,
+	}))
+	p.AddPayload(sso)
+
+	if have, want := p.ReferencedCertificateUUIDs(), []string{identity.PayloadUUID}; !reflect.DeepEqual(have, want) {
+		t.Errorf("have %v, want %v", have, want)
+	}
+
+	old := identity.PayloadUUID
+	p.RegenerateUUIDs()
+	if identity.PayloadUUID == old {
+		t.Error("expected identity UUID to change")
+	}
+	data, err := sso.Kerberos()
+	fatalIf(t, err)
+	if have, want := data.PKINITCertificateUUID, identity.PayloadUUID; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if len(p.UnreferencedCertificatePayloads()) != 0 {
+		t.Error("expected the PKINIT identity to be referenced")
+	}
+}
@@ -0,0 +1,88 @@
+package cfgprofiles
+
+import (
+	"errors"
+	"fmt"
+)
+
+// validator is implemented by payloads which can check themselves for
+// invalid values.
+type validator interface {
+	Validate() error
+}
+
+// profileValidator is implemented by payloads which need the enclosing
+// profile to check their references to other payloads.
+type profileValidator interface {
+	validateProfile(p *Profile) error
+}
+
+// Validate checks the profile and each of its payloads for invalid values
+// and combinations of keys. A single problem results in that error being
+// returned; several result in Errors.
+func (p *Profile) Validate() error {
+	var errs Errors
+	if p.PayloadType != PayloadTypeConfiguration {
+		errs = append(errs, fmt.Errorf("profile PayloadType must be %q, have %q", PayloadTypeConfiguration, p.PayloadType))
+	}
+	if p.HasRemovalPasscode && p.PayloadRemovalDisallowed {
+		errs = append(errs, errors.New("removal passcode has no effect when removal is disallowed"))
+	}
+	if p.DurationUntilRemoval < 0 {
+		errs = append(errs, fmt.Errorf("invalid DurationUntilRemoval: %v", p.DurationUntilRemoval))
+	}
+	for i, pc := range p.PayloadContent {
+		if v, ok := pc.Payload.(validator); ok {
+			if err := v.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("payload %d (%s): %w", i, payloadUUID(pc.Payload), err))
+			}
+		}
+		if v, ok := pc.Payload.(profileValidator); ok {
+			if err := v.validateProfile(p); err != nil {
+				errs = append(errs, fmt.Errorf("payload %d (%s): %w", i, payloadUUID(pc.Payload), err))
+			}
+		}
+	}
+	return errs.errOrNil()
+}
+
+// payloadUUID returns the PayloadUUID of payload pld or an empty string.
+func payloadUUID(pld interface{}) string {
+	if c := CommonPayload(pld); c != nil {
+		return c.PayloadUUID
+	}
+	return ""
+}
+
+// isIdentityPayload reports whether pld can provide an identity
+// (certificate and private key) for use by other payloads.
+func isIdentityPayload(pld interface{}) bool {
+	switch pld.(type) {
+	case *SCEPPayload, *ACMECertificatePayload, *CertificatePKCS12Payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateEnrollment checks that the profile is usable as an MDM
+// enrollment profile: it must contain exactly one MDM payload whose
+// IdentityCertificateUUID refers to an identity payload in the profile.
+func (p *Profile) ValidateEnrollment() error {
+	mdms := p.MDMPayloads()
+	if len(mdms) != 1 {
+		return fmt.Errorf("enrollment profile must contain exactly one MDM payload, found %d", len(mdms))
+	}
+	u := mdms[0].IdentityCertificateUUID
+	if u == "" {
+		return errors.New("MDM payload has no IdentityCertificateUUID")
+	}
+	pld := p.PayloadByUUID(u)
+	if pld == nil {
+		return fmt.Errorf("IdentityCertificateUUID %q: no payload with UUID", u)
+	}
+	if !isIdentityPayload(pld) {
+		return fmt.Errorf("IdentityCertificateUUID %q: %T is not an identity payload", u, pld)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package cfgprofiles
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProfileValidateRemoval(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	fatalIf(t, p.Validate())
+
+	p.HasRemovalPasscode = true
+	p.PayloadRemovalDisallowed = true
+	if err := p.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	p = NewProfile("com.example.profile")
+	p.DurationUntilRemoval = -1
+	if err := p.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestProfileValidatePayloadType(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	fatalIf(t, p.Validate())
+
+	p.PayloadType = PayloadTypeMDM
+	if err := p.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+	p.PayloadType = "configuration"
+	if err := p.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestProfileValidatePayloads(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	p.HasRemovalPasscode = true
+	p.PayloadRemovalDisallowed = true
+	p.AddPayload(NewCertificatePKCS1Payload("com.example.profile.pkcs1"))
+
+	var errs Errors
+	if err := p.Validate(); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("expected 2 errors, have %v", err)
+	}
+}
+
+func TestProfileValidateEnrollment(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	if err := p.ValidateEnrollment(); err == nil {
+		t.Error("expected an error")
+	}
+
+	mdm := NewMDMPayload("com.example.profile.mdm")
+	p.AddPayload(mdm)
+	if err := p.ValidateEnrollment(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pkcs1 := NewCertificatePKCS1Payload("com.example.profile.pkcs1")
+	p.AddPayload(pkcs1)
+	mdm.IdentityCertificateUUID = pkcs1.PayloadUUID
+	if err := p.ValidateEnrollment(); err == nil {
+		t.Error("expected an error")
+	}
+
+	scep := NewSCEPPayload("com.example.profile.scep")
+	p.AddPayload(scep)
+	mdm.IdentityCertificateUUID = scep.PayloadUUID
+	fatalIf(t, p.ValidateEnrollment())
+
+	p.AddPayload(NewMDMPayload("com.example.profile.mdm2"))
+	if err := p.ValidateEnrollment(); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,32 @@
+package cfgprofiles
+
+// BumpVersion increments the PayloadVersion of the profile so that
+// devices re-apply it when installed over an earlier version. If
+// payloads is true the PayloadVersion of each payload is incremented as
+// well.
+func (p *Profile) BumpVersion(payloads bool) {
+	p.PayloadVersion++
+	if !payloads {
+		return
+	}
+	for _, pc := range p.PayloadContent {
+		if pld := CommonPayload(pc.Payload); pld != nil {
+			pld.PayloadVersion++
+		}
+	}
+}
+
+// BumpVersionIfChanged calls BumpVersion if the ContentHash of the
+// profile differs from prev, the hash returned by an earlier call. It
+// returns the ContentHash of the profile after any bump, to be stored
+// and passed as prev next time, and whether the version was bumped. An
+// empty prev never bumps the version.
+func (p *Profile) BumpVersionIfChanged(prev string, payloads bool) (hash string, bumped bool, err error) {
+	hash, err = p.ContentHash()
+	if err != nil || prev == "" || hash == prev {
+		return hash, false, err
+	}
+	p.BumpVersion(payloads)
+	hash, err = p.ContentHash()
+	return hash, err == nil, err
+}
@@ -0,0 +1,59 @@
+package cfgprofiles
+
+import "testing"
+
+func TestBumpVersion(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	pld := NewMDMPayload("com.example.profile.mdm")
+	p.AddPayload(pld)
+
+	p.BumpVersion(false)
+	if have, want := p.PayloadVersion, 2; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	if have, want := pld.PayloadVersion, 1; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+
+	p.BumpVersion(true)
+	if have, want := p.PayloadVersion, 3; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+	if have, want := pld.PayloadVersion, 2; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+}
+
+func TestBumpVersionIfChanged(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	pld := NewMDMPayload("com.example.profile.mdm")
+	p.AddPayload(pld)
+
+	hash, bumped, err := p.BumpVersionIfChanged("", false)
+	fatalIf(t, err)
+	if bumped {
+		t.Error("expected no bump without a previous hash")
+	}
+
+	hash2, bumped, err := p.BumpVersionIfChanged(hash, false)
+	fatalIf(t, err)
+	if bumped || hash2 != hash {
+		t.Error("expected no bump for unchanged content")
+	}
+
+	pld.ServerURL = "https://mdm.example.com/mdm"
+	hash3, bumped, err := p.BumpVersionIfChanged(hash, false)
+	fatalIf(t, err)
+	if !bumped {
+		t.Error("expected bump for changed content")
+	}
+	if have, want := p.PayloadVersion, 2; have != want {
+		t.Errorf("have %d, want %d", have, want)
+	}
+
+	_, bumped, err = p.BumpVersionIfChanged(hash3, false)
+	fatalIf(t, err)
+	if bumped {
+		t.Error("expected no bump after storing the returned hash")
+	}
+}
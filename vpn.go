@@ -0,0 +1,85 @@
+package cfgprofiles
+
+// VPNSettings represents the VPN dictionary of the VPNPayload used by
+// the L2TP, PPTP and IPSec VPN types.
+// See https://developer.apple.com/documentation/devicemanagement/vpn/vpn
+type VPNSettings struct {
+	AuthName               string `plist:",omitempty"`
+	AuthPassword           string `plist:",omitempty"`
+	AuthenticationMethod   string `plist:",omitempty"`
+	RemoteAddress          string `plist:",omitempty"`
+	PayloadCertificateUUID string `plist:",omitempty"`
+}
+
+// VPNIKEv2 represents the IKEv2 dictionary of the VPNPayload.
+// See https://developer.apple.com/documentation/devicemanagement/vpn/ikev2
+type VPNIKEv2 struct {
+	RemoteAddress          string `plist:",omitempty"`
+	LocalIdentifier        string `plist:",omitempty"`
+	RemoteIdentifier       string `plist:",omitempty"`
+	AuthenticationMethod   string `plist:",omitempty"` // Possible values: None, SharedSecret, Certificate
+	ExtendedAuthEnabled    int    `plist:",omitempty"`
+	AuthName               string `plist:",omitempty"`
+	AuthPassword           string `plist:",omitempty"`
+	SharedSecret           string `plist:",omitempty"`
+	PayloadCertificateUUID string `plist:",omitempty"`
+}
+
+// VPNPayload represents the "com.apple.vpn.managed" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/vpn
+//
+// Keys which are not modeled by a field are kept in ExtraFields so that
+// they survive a round-trip.
+type VPNPayload struct {
+	Payload
+	binding
+	UserDefinedName string                 `plist:",omitempty"`
+	VPNType         string                 // Possible values: L2TP, PPTP, IPSec, IKEv2, AlwaysOn, VPN, TransparentProxy
+	VPNSubType      string                 `plist:",omitempty"`
+	VPN             *VPNSettings           `plist:",omitempty"`
+	IKEv2           *VPNIKEv2              `plist:",omitempty"`
+	ExtraFields     map[string]interface{} `plist:"-"`
+}
+
+// vpnPayload has the fields of VPNPayload without its custom
+// (un)marshalling.
+type vpnPayload VPNPayload
+
+// MarshalPlist marshals pl including its ExtraFields entries.
+func (pl *VPNPayload) MarshalPlist() (interface{}, error) {
+	return marshalWithExtra((*vpnPayload)(pl), pl.ExtraFields)
+}
+
+// UnmarshalPlist unmarshals pl, placing unknown keys into ExtraFields.
+func (pl *VPNPayload) UnmarshalPlist(f func(interface{}) error) error {
+	var named vpnPayload
+	extra, err := unmarshalExtra(f, &named)
+	if err != nil {
+		return err
+	}
+	named.ExtraFields = extra
+	*pl = VPNPayload(named)
+	return nil
+}
+
+// NewVPNPayload creates a new payload with identifier i
+func NewVPNPayload(i string) *VPNPayload {
+	return &VPNPayload{
+		Payload: *NewPayload(PayloadTypeVPN, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeVPN.
+func (pl *VPNPayload) ExpectedPayloadType() string {
+	return PayloadTypeVPN
+}
+
+// VPNPayloads returns a slice of all payloads of that type
+func (p *Profile) VPNPayloads() (plds []*VPNPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*VPNPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
@@ -0,0 +1,242 @@
+package cfgprofiles
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+)
+
+// Wi-Fi encryption types for the EncryptionType key. Enterprise (802.1X)
+// networks use one of the WEP or WPA types with an EAPClientConfiguration.
+const (
+	WiFiEncryptionTypeWEP  = "WEP"
+	WiFiEncryptionTypeWPA  = "WPA"
+	WiFiEncryptionTypeWPA2 = "WPA2"
+	WiFiEncryptionTypeWPA3 = "WPA3"
+	WiFiEncryptionTypeAny  = "Any"
+	WiFiEncryptionTypeNone = "None"
+)
+
+// EAPClientConfiguration represents the EAPClientConfiguration of the WiFiPayload.
+// See https://developer.apple.com/documentation/devicemanagement/wifi/eapclientconfiguration
+type EAPClientConfiguration struct {
+	AcceptEAPTypes               []int    `plist:",omitempty"`
+	UserName                     string   `plist:",omitempty"`
+	UserPassword                 string   `plist:",omitempty"`
+	OuterIdentity                string   `plist:",omitempty"`
+	PayloadCertificateAnchorUUID []string `plist:",omitempty"`
+	TLSTrustedServerNames        []string `plist:",omitempty"`
+	TLSMinimumVersion            string   `plist:",omitempty"`
+	TLSMaximumVersion            string   `plist:",omitempty"`
+	TTLSInnerAuthentication      string   `plist:",omitempty"`
+}
+
+// eapClientConfigurationKeys are the 802.1X keys of the
+// EAPClientConfiguration.
+var eapClientConfigurationKeys = plistKeys(reflect.TypeOf(EAPClientConfiguration{}))
+
+// QoSMarkingPolicy represents the QoSMarkingPolicy of the WiFiPayload.
+// See https://developer.apple.com/documentation/devicemanagement/wifi/qosmarkingpolicy
+//
+// Note that when a policy is present only the apps in the allow list
+// (and optionally Apple audio/video calls) are marked. An empty allow
+// list with QoS marking enabled is valid and marks no other apps.
+type QoSMarkingPolicy struct {
+	QoSMarkingEnabled                   *bool    `plist:",omitempty"` // default true
+	QoSMarkingAppleAudioVideoCalls      *bool    `plist:",omitempty"` // default true
+	QoSMarkingAllowListAppIdentifiers   []string `plist:",omitempty"`
+	QoSMarkingWhitelistedAppIdentifiers []string `plist:",omitempty"` // deprecated
+}
+
+// HS20 contains the Hotspot 2.0 (Passpoint) keys of the WiFiPayload.
+// It is embedded in WiFiPayload so the keys are marshaled at the top
+// level of the payload as Apple expects. The keys only apply when
+// IsHotspot is true.
+type HS20 struct {
+	DomainName                    string   `plist:",omitempty"`
+	DisplayedOperatorName         string   `plist:",omitempty"`
+	ServiceProviderRoamingEnabled bool     `plist:",omitempty"`
+	RoamingConsortiumOIs          []string `plist:",omitempty"`
+	NAIRealmNames                 []string `plist:",omitempty"`
+	MCCAndMNCs                    []string `plist:",omitempty"`
+}
+
+// WiFiPayload represents the "com.apple.wifi.managed" PayloadType.
+// See https://developer.apple.com/documentation/devicemanagement/wifi
+//
+// The DomainName key is part of the embedded HS20 keys. Keys which are
+// not modeled by a field are kept in ExtraFields so that they survive a
+// round-trip.
+type WiFiPayload struct {
+	Payload
+	binding
+	SSID           string `plist:"SSID_STR,omitempty"`
+	HEXSSID        []byte `plist:",omitempty"`
+	HiddenNetwork  bool   `plist:"HIDDEN_NETWORK,omitempty"`
+	AutoJoin       *bool  `plist:",omitempty"` // default true
+	Priority       *int   `plist:",omitempty"` // auto-join priority
+	CaptiveBypass  bool   `plist:",omitempty"`
+	EncryptionType string `plist:",omitempty"`
+	IsHotspot      bool   `plist:",omitempty"`
+	HS20
+	Password               string                  `plist:",omitempty"`
+	PayloadCertificateUUID string                  `plist:",omitempty"`
+	EAPClientConfiguration *EAPClientConfiguration `plist:",omitempty"`
+	ProxySettings
+	QoSMarkingPolicy                   *QoSMarkingPolicy      `plist:",omitempty"`
+	DisableAssociationMACRandomization *bool                  `plist:",omitempty"` // default false
+	ExtraFields                        map[string]interface{} `plist:"-"`
+}
+
+// wifiPayload has the fields of WiFiPayload without its custom
+// (un)marshalling.
+type wifiPayload WiFiPayload
+
+// MarshalPlist marshals pl including its ExtraFields entries.
+func (pl *WiFiPayload) MarshalPlist() (interface{}, error) {
+	return marshalWithExtra((*wifiPayload)(pl), pl.ExtraFields)
+}
+
+// UnmarshalPlist unmarshals pl, placing unknown keys into ExtraFields.
+func (pl *WiFiPayload) UnmarshalPlist(f func(interface{}) error) error {
+	var named wifiPayload
+	extra, err := unmarshalExtra(f, &named)
+	if err != nil {
+		return err
+	}
+	named.ExtraFields = extra
+	*pl = WiFiPayload(named)
+	return nil
+}
+
+// NewWiFiPayload creates a new payload with identifier i
+func NewWiFiPayload(i string) *WiFiPayload {
+	return &WiFiPayload{
+		Payload: *NewPayload(PayloadTypeWiFi, i),
+	}
+}
+
+// ExpectedPayloadType returns PayloadTypeWiFi.
+func (pl *WiFiPayload) ExpectedPayloadType() string {
+	return PayloadTypeWiFi
+}
+
+// SetSSID sets the network SSID. SSIDs which are valid UTF-8 are set in
+// SSID, otherwise the raw bytes are set in HEXSSID. The other key is
+// cleared.
+func (pl *WiFiPayload) SetSSID(ssid []byte) {
+	if utf8.Valid(ssid) {
+		pl.SSID, pl.HEXSSID = string(ssid), nil
+		return
+	}
+	pl.SSID, pl.HEXSSID = "", append([]byte{}, ssid...)
+}
+
+// SSIDBytes returns the network SSID. HEXSSID is preferred over SSID if
+// both are set, as on Apple devices.
+func (pl *WiFiPayload) SSIDBytes() []byte {
+	if len(pl.HEXSSID) > 0 {
+		return pl.HEXSSID
+	}
+	return []byte(pl.SSID)
+}
+
+// DisableMACRandomization disables private (randomized) MAC addresses
+// when associating with this network so the device presents a stable
+// MAC address, e.g. for 802.1X networks which identify devices by MAC.
+func (pl *WiFiPayload) DisableMACRandomization() {
+	disable := true
+	pl.DisableAssociationMACRandomization = &disable
+}
+
+// SetPriority sets the auto-join priority of the network relative to
+// other configured networks and enables AutoJoin, as the priority only
+// applies to networks which are joined automatically.
+func (pl *WiFiPayload) SetPriority(priority int) {
+	autoJoin := true
+	pl.AutoJoin = &autoJoin
+	pl.Priority = &priority
+}
+
+// Validate checks the Wi-Fi payload for invalid values and combinations
+// of keys. An EncryptionType of None cannot be used with an
+// EAPClientConfiguration, a pre-shared Password and an
+// EAPClientConfiguration are mutually exclusive, a client identity
+// (PayloadCertificateUUID) or 802.1X keys such as UserName placed at the
+// top level of the payload require an EAPClientConfiguration and a
+// Priority cannot be set when AutoJoin is disabled.
+//
+// At most one of Password and EAPClientConfiguration is required: an
+// encrypted network with neither is valid as the device prompts for the
+// password when joining.
+func (pl *WiFiPayload) Validate() error {
+	switch pl.EncryptionType {
+	case "", WiFiEncryptionTypeWEP, WiFiEncryptionTypeWPA, WiFiEncryptionTypeWPA2,
+		WiFiEncryptionTypeWPA3, WiFiEncryptionTypeAny, WiFiEncryptionTypeNone:
+	default:
+		return fmt.Errorf("unknown Wi-Fi EncryptionType: %q", pl.EncryptionType)
+	}
+	if len(pl.HEXSSID) > 0 && pl.SSID != "" && pl.SSID != string(pl.HEXSSID) {
+		return errors.New("Wi-Fi SSID and HEXSSID do not match")
+	}
+	if pl.EncryptionType == WiFiEncryptionTypeNone && pl.EAPClientConfiguration != nil {
+		return errors.New("Wi-Fi EAPClientConfiguration requires an EncryptionType other than None")
+	}
+	if pl.Password != "" && pl.EAPClientConfiguration != nil {
+		return errors.New("Wi-Fi Password and EAPClientConfiguration are mutually exclusive")
+	}
+	if pl.PayloadCertificateUUID != "" && pl.EAPClientConfiguration == nil {
+		return errors.New("Wi-Fi PayloadCertificateUUID requires an EAPClientConfiguration")
+	}
+	if pl.EAPClientConfiguration == nil {
+		for k := range pl.ExtraFields {
+			if eapClientConfigurationKeys[k] {
+				return fmt.Errorf("Wi-Fi %s requires an EAPClientConfiguration", k)
+			}
+		}
+	}
+	if pl.Priority != nil && pl.AutoJoin != nil && !*pl.AutoJoin {
+		return errors.New("Wi-Fi Priority requires AutoJoin")
+	}
+	if !pl.IsHotspot && !reflect.DeepEqual(pl.HS20, HS20{}) {
+		return errors.New("Wi-Fi Hotspot 2.0 keys require IsHotspot")
+	}
+	return pl.validateProxy()
+}
+
+// SetPersonal configures a pre-shared key (personal) network with
+// password, clearing any enterprise configuration. The EncryptionType is
+// set to WPA2 unless it is already set to another encrypted type.
+func (pl *WiFiPayload) SetPersonal(password string) {
+	pl.Password = password
+	pl.EAPClientConfiguration = nil
+	pl.PayloadCertificateUUID = ""
+	pl.setEncryptedType()
+}
+
+// SetEnterprise configures an enterprise (802.1X) network with cfg,
+// clearing any pre-shared key. The EncryptionType is set to WPA2 unless
+// it is already set to another encrypted type.
+func (pl *WiFiPayload) SetEnterprise(cfg *EAPClientConfiguration) {
+	pl.EAPClientConfiguration = cfg
+	pl.Password = ""
+	pl.setEncryptedType()
+}
+
+// setEncryptedType sets the EncryptionType to WPA2 if it is unset or None.
+func (pl *WiFiPayload) setEncryptedType() {
+	if pl.EncryptionType == "" || pl.EncryptionType == WiFiEncryptionTypeNone {
+		pl.EncryptionType = WiFiEncryptionTypeWPA2
+	}
+}
+
+// WiFiPayloads returns a slice of all payloads of that type
+func (p *Profile) WiFiPayloads() (plds []*WiFiPayload) {
+	for _, pc := range p.PayloadContent {
+		if pld, ok := pc.Payload.(*WiFiPayload); ok {
+			plds = append(plds, pld)
+		}
+	}
+	return
+}
@@ -0,0 +1,352 @@
+package cfgprofiles
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestWiFiPayloadQoSMarkingPolicy(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+	enabled := true
+	pl.QoSMarkingPolicy = &QoSMarkingPolicy{
+		QoSMarkingEnabled:                 &enabled,
+		QoSMarkingAllowListAppIdentifiers: []string{},
+	}
+	p.AddPayload(pl)
+
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	if !bytes.Contains(b, []byte("<key>QoSMarkingEnabled</key><true/>")) {
+		t.Error("expected QoSMarkingEnabled key")
+	}
+	if bytes.Contains(b, []byte("QoSMarkingAllowListAppIdentifiers")) {
+		t.Error("expected empty allow list to be omitted")
+	}
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.WiFiPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	want := &QoSMarkingPolicy{QoSMarkingEnabled: &enabled}
+	if !reflect.DeepEqual(pls[0].QoSMarkingPolicy, want) {
+		t.Errorf("have %#+v, want %#+v", pls[0].QoSMarkingPolicy, want)
+	}
+
+	pl.QoSMarkingPolicy = nil
+	b, err = plist.Marshal(p)
+	fatalIf(t, err)
+	if bytes.Contains(b, []byte("QoSMarking")) {
+		t.Error("expected no QoS keys")
+	}
+}
+
+func TestWiFiPayloadHS20(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+
+	b, err := plist.Marshal(pl)
+	fatalIf(t, err)
+	for _, key := range []string{"DomainName", "RoamingConsortiumOIs", "NAIRealmNames", "MCCAndMNCs", "IsHotspot"} {
+		if bytes.Contains(b, []byte(key)) {
+			t.Errorf("unexpected key %q", key)
+		}
+	}
+
+	pl.HS20 = HS20{
+		DomainName:           "example.com",
+		RoamingConsortiumOIs: []string{"5A03BA0000"},
+		NAIRealmNames:        []string{"example.com"},
+		MCCAndMNCs:           []string{"310410"},
+	}
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+	pl.IsHotspot = true
+	fatalIf(t, pl.Validate())
+
+	b, err = plist.Marshal(pl)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>DomainName</key><string>example.com</string>")) {
+		t.Error("expected top level DomainName key")
+	}
+
+	pl2 := &WiFiPayload{}
+	fatalIf(t, plist.Unmarshal(b, pl2))
+	if !reflect.DeepEqual(pl2, pl) {
+		t.Errorf("have %#+v, want %#+v", pl2, pl)
+	}
+}
+
+func TestWiFiPayloadDisableMACRandomization(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+	b, err := plist.Marshal(pl)
+	fatalIf(t, err)
+	if bytes.Contains(b, []byte("DisableAssociationMACRandomization")) {
+		t.Error("expected DisableAssociationMACRandomization to be omitted")
+	}
+
+	pl.DisableMACRandomization()
+	b, err = plist.Marshal(pl)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>DisableAssociationMACRandomization</key><true/>")) {
+		t.Error("expected DisableAssociationMACRandomization key")
+	}
+
+	// an explicit false is kept
+	disable := false
+	pl.DisableAssociationMACRandomization = &disable
+	b, err = plist.Marshal(pl)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>DisableAssociationMACRandomization</key><false/>")) {
+		t.Error("expected DisableAssociationMACRandomization key")
+	}
+}
+
+func TestWiFiPayloadEncryptionType(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+	fatalIf(t, pl.Validate())
+
+	pl.EncryptionType = "WPA2 Enterprise"
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	pl.EncryptionType = WiFiEncryptionTypeWPA2
+	pl.PayloadCertificateUUID = "8BF53919-B83E-4280-A40C-0407FB6AF341"
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+
+	// 802.1X keys outside of an EAPClientConfiguration
+	pl.EncryptionType = WiFiEncryptionTypeWPA3
+	pl.PayloadCertificateUUID = ""
+	pl.ExtraFields = map[string]interface{}{"UserName": "user"}
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+	pl.ExtraFields = nil
+
+	pl.EncryptionType = WiFiEncryptionTypeWPA2
+	pl.PayloadCertificateUUID = "8BF53919-B83E-4280-A40C-0407FB6AF341"
+	pl.EAPClientConfiguration = &EAPClientConfiguration{AcceptEAPTypes: []int{13}}
+	fatalIf(t, pl.Validate())
+
+	pl.EncryptionType = WiFiEncryptionTypeNone
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestWiFiPayloadHEXSSID(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SetSSID([]byte("Example"))
+	if have, want := pl.SSID, "Example"; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	if pl.HEXSSID != nil {
+		t.Error("expected nil HEXSSID")
+	}
+
+	raw := []byte{'E', 'x', 0xff, 0xfe}
+	pl.SetSSID(raw)
+	if pl.SSID != "" {
+		t.Errorf("have %q, want empty SSID", pl.SSID)
+	}
+	if !bytes.Equal(pl.SSIDBytes(), raw) {
+		t.Errorf("have %x, want %x", pl.SSIDBytes(), raw)
+	}
+	fatalIf(t, pl.Validate())
+
+	b, err := plist.Marshal(pl)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>HEXSSID</key><data>")) {
+		t.Error("expected HEXSSID data")
+	}
+	if bytes.Contains(b, []byte("SSID_STR")) {
+		t.Error("expected SSID_STR to be omitted")
+	}
+
+	// HEXSSID is preferred when both are set and must match
+	pl.SSID = "Other"
+	if !bytes.Equal(pl.SSIDBytes(), raw) {
+		t.Errorf("have %x, want %x", pl.SSIDBytes(), raw)
+	}
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+	pl.SSID = string(raw)
+	fatalIf(t, pl.Validate())
+}
+
+func TestWiFiPayloadExtraFields(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+	pl.ExtraFields = map[string]interface{}{
+		"CaptiveBypass": true,
+		"SetupModes":    []interface{}{"System"},
+	}
+	b, err := plist.Marshal(pl)
+	fatalIf(t, err)
+	for _, s := range []string{
+		"<key>CaptiveBypass</key><true/>",
+		"<key>SetupModes</key><array><string>System</string></array>",
+		"<key>SSID_STR</key><string>Example</string>",
+	} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Errorf("expected %s", s)
+		}
+	}
+
+	// CaptiveBypass is now modeled and unmarshals into its field
+	want := *pl
+	want.CaptiveBypass = true
+	want.ExtraFields = map[string]interface{}{"SetupModes": []interface{}{"System"}}
+	pl2 := &WiFiPayload{}
+	fatalIf(t, plist.Unmarshal(b, pl2))
+	if !reflect.DeepEqual(pl2, &want) {
+		t.Errorf("have %#+v, want %#+v", pl2, &want)
+	}
+
+	// extra fields may not shadow modeled keys
+	pl.ExtraFields["SSID_STR"] = "Other"
+	if _, err := plist.Marshal(pl); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestVPNPayloadExtraFields(t *testing.T) {
+	p := NewProfile("com.example.profile")
+	pl := NewVPNPayload("com.example.profile.vpn")
+	pl.VPNType = "IKEv2"
+	pl.IKEv2 = &VPNIKEv2{RemoteAddress: "vpn.example.com"}
+	pl.ExtraFields = map[string]interface{}{"OnDemandEnabled": uint64(1)}
+	p.AddPayload(pl)
+	b, err := plist.Marshal(p)
+	fatalIf(t, err)
+
+	p2 := &Profile{}
+	fatalIf(t, plist.Unmarshal(b, p2))
+	pls := p2.VPNPayloads()
+	if len(pls) != 1 {
+		t.Fatal("payload count is not 1")
+	}
+	if !reflect.DeepEqual(pls[0], pl) {
+		t.Errorf("have %#+v, want %#+v", pls[0], pl)
+	}
+}
+
+func TestWiFiPayloadPersonalEnterprise(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+
+	// no pre-shared key: the device prompts for it
+	pl.EncryptionType = WiFiEncryptionTypeWPA2
+	fatalIf(t, pl.Validate())
+
+	pl.SetPersonal("secret")
+	if pl.Password != "secret" || pl.EncryptionType != WiFiEncryptionTypeWPA2 {
+		t.Errorf("unexpected personal configuration %#+v", pl)
+	}
+	fatalIf(t, pl.Validate())
+
+	pl.EncryptionType = WiFiEncryptionTypeWPA3
+	pl.PayloadCertificateUUID = "8BF53919-B83E-4280-A40C-0407FB6AF341"
+	pl.SetEnterprise(&EAPClientConfiguration{AcceptEAPTypes: []int{13}})
+	if pl.Password != "" || pl.EAPClientConfiguration == nil {
+		t.Errorf("unexpected enterprise configuration %#+v", pl)
+	}
+	if have, want := pl.EncryptionType, WiFiEncryptionTypeWPA3; have != want {
+		t.Errorf("have %q, want %q", have, want)
+	}
+	fatalIf(t, pl.Validate())
+
+	pl.SetPersonal("secret")
+	if pl.EAPClientConfiguration != nil || pl.PayloadCertificateUUID != "" {
+		t.Error("expected enterprise configuration to be cleared")
+	}
+	fatalIf(t, pl.Validate())
+
+	// both a pre-shared key and an EAP configuration
+	pl.EAPClientConfiguration = &EAPClientConfiguration{AcceptEAPTypes: []int{25}}
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestWiFiPayloadUnmodeledExtraFields(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+	pl.ExtraFields = map[string]interface{}{
+		"AllowJoinBeforeFirstUnlock": true,
+	}
+	b, err := plist.Marshal(pl)
+	fatalIf(t, err)
+	if !bytes.Contains(b, []byte("<key>AllowJoinBeforeFirstUnlock</key><true/>")) {
+		t.Error("expected AllowJoinBeforeFirstUnlock")
+	}
+
+	pl2 := &WiFiPayload{}
+	fatalIf(t, plist.Unmarshal(b, pl2))
+	if !reflect.DeepEqual(pl2, pl) {
+		t.Errorf("have %#+v, want %#+v", pl2, pl)
+	}
+
+	// set modeled keys may not be shadowed
+	pl.CaptiveBypass = true
+	pl.ExtraFields["CaptiveBypass"] = false
+	if _, err := plist.Marshal(pl); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestWiFiPayloadPriority(t *testing.T) {
+	pl := NewWiFiPayload("com.example.profile.wifi")
+	pl.SSID = "Example"
+	b, err := plist.Marshal(pl)
+	fatalIf(t, err)
+	for _, k := range []string{"Priority", "CaptiveBypass"} {
+		if bytes.Contains(b, []byte("<key>"+k+"</key>")) {
+			t.Errorf("expected %s to be omitted", k)
+		}
+	}
+
+	pl.SetPriority(0)
+	pl.CaptiveBypass = true
+	fatalIf(t, pl.Validate())
+	b, err = plist.Marshal(pl)
+	fatalIf(t, err)
+	for _, s := range []string{
+		"<key>Priority</key><integer>0</integer>",
+		"<key>CaptiveBypass</key><true/>",
+		"<key>AutoJoin</key><true/>",
+	} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Errorf("expected %s", s)
+		}
+	}
+
+	var pl2 WiFiPayload
+	fatalIf(t, plist.Unmarshal(b, &pl2))
+	if pl2.Priority == nil || *pl2.Priority != 0 || !pl2.CaptiveBypass {
+		t.Error("expected Priority and CaptiveBypass to round-trip")
+	}
+	if len(pl2.ExtraFields) != 0 {
+		t.Errorf("have %v, want no extra fields", pl2.ExtraFields)
+	}
+
+	autoJoin := false
+	pl.AutoJoin = &autoJoin
+	if err := pl.Validate(); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,71 @@
+package cfgprofiles
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxZipProfileSize is the largest .mobileconfig file, in bytes, read
+// from a zip archive. It guards against zip bombs; real profiles, even
+// with embedded certificates and fonts, are far smaller.
+const maxZipProfileSize = 16 << 20
+
+// ParseProfilesFromZip parses every .mobileconfig file in the zip archive
+// r of size bytes. Signed profiles are parsed from their signed content
+// without verifying the signature. Files which fail to parse do not stop
+// the others from being parsed: the successfully parsed profiles are
+// returned along with an error naming each failed file. A single failure
+// is returned as that error; several as Errors. Files larger than 16 MiB
+// uncompressed fail without being read.
+func ParseProfilesFromZip(r io.ReaderAt, size int64) ([]*Profile, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var profiles []*Profile
+	var errs Errors
+	for _, f := range zr.File {
+		name := path.Base(f.Name)
+		if f.FileInfo().IsDir() ||
+			strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(name, "._") ||
+			!strings.EqualFold(path.Ext(name), ".mobileconfig") {
+			continue
+		}
+		p, err := parseZipProfile(f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Name, err))
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, errs.errOrNil()
+}
+
+// parseZipProfile reads and parses the, possibly signed, profile in f.
+func parseZipProfile(f *zip.File) (*Profile, error) {
+	if f.UncompressedSize64 > maxZipProfileSize {
+		return nil, fmt.Errorf("profile size %d exceeds limit of %d bytes", f.UncompressedSize64, maxZipProfileSize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// the header size may not match the content
+	b, err := io.ReadAll(io.LimitReader(rc, maxZipProfileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxZipProfileSize {
+		return nil, fmt.Errorf("profile exceeds limit of %d bytes", maxZipProfileSize)
+	}
+	if isSignedData(b) {
+		if b, err = signedContent(b); err != nil {
+			return nil, err
+		}
+	}
+	return ParseProfile(b)
+}
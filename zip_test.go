@@ -0,0 +1,77 @@
+package cfgprofiles
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/micromdm/plist"
+)
+
+func TestParseProfilesFromZip(t *testing.T) {
+	p := NewProfile("com.example.plain")
+	plain, err := plist.MarshalIndent(p, "\t")
+	fatalIf(t, err)
+	cert, key := newTestSigner(t, "Zip Signer")
+	signed, err := SignProfile(NewProfile("com.example.signed"), cert, key, nil)
+	fatalIf(t, err)
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"bundle/plain.mobileconfig", plain},
+		{"bundle/signed.MOBILECONFIG", signed},
+		{"bundle/broken.mobileconfig", []byte("not a profile")},
+		{"bundle/README.txt", []byte("ignored")},
+		{"__MACOSX/bundle/._plain.mobileconfig", []byte("ignored")},
+	} {
+		w, err := zw.Create(f.name)
+		fatalIf(t, err)
+		_, err = w.Write(f.data)
+		fatalIf(t, err)
+	}
+	fatalIf(t, zw.Close())
+
+	profiles, err := ParseProfilesFromZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err == nil {
+		t.Error("expected an error")
+	} else if !bytes.Contains([]byte(err.Error()), []byte("broken.mobileconfig")) {
+		t.Errorf("expected error to name the file: %v", err)
+	}
+	var errs Errors
+	if errors.As(err, &errs) {
+		t.Errorf("expected a single error, have %d", len(errs))
+	}
+
+	if len(profiles) != 2 {
+		t.Fatalf("have %d profiles, want 2", len(profiles))
+	}
+	for i, want := range []string{"com.example.plain", "com.example.signed"} {
+		if have := profiles[i].PayloadIdentifier; have != want {
+			t.Errorf("have %q, want %q", have, want)
+		}
+	}
+}
+
+func TestParseProfilesFromZipSizeLimit(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.Create("large.mobileconfig")
+	fatalIf(t, err)
+	_, err = w.Write(make([]byte, maxZipProfileSize+1))
+	fatalIf(t, err)
+	fatalIf(t, zw.Close())
+
+	profiles, err := ParseProfilesFromZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Errorf("expected size limit error, have %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Errorf("have %d profiles, want 0", len(profiles))
+	}
+}